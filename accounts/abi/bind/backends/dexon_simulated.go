@@ -0,0 +1,286 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
+
+	"github.com/dexon-foundation/dexon"
+	"github.com/dexon-foundation/dexon/accounts/abi/bind"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/eth/filters"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/event"
+)
+
+// This nil assignment ensures compile time that DexonSimulatedBackend implements bind.ContractBackend.
+var _ bind.ContractBackend = (*DexonSimulatedBackend)(nil)
+
+var errGovernanceReverted = errors.New("governance contract call reverted")
+
+// DexonSimulatedBackend implements bind.ContractBackend, simulating a DEXON
+// blockchain in the background. The genesis initialises the governance
+// contract and the blocks are finalized by the dexcon engine, so contracts
+// calling into the governance contract can be tested, moving through rounds
+// and registering, staking and fining test nodes on the way.
+//
+// A single notary staked in the genesis proposes all the blocks.
+type DexonSimulatedBackend struct {
+	*SimulatedBackend
+
+	engine *dexcon.Dexcon
+	gov    *core.Governance
+
+	notary *ecdsa.PrivateKey // Sole notary proposing the blocks
+	owner  *ecdsa.PrivateKey // Owner of the governance contract reporting the fines
+}
+
+// NewDexonSimulatedBackend creates a new binding backend using a simulated
+// DEXON blockchain for testing purposes. The accounts of alloc may be staked
+// nodes as well.
+func NewDexonSimulatedBackend(alloc core.GenesisAlloc, gasLimit uint64) *DexonSimulatedBackend {
+	notary, _ := crypto.GenerateKey()
+	owner, _ := crypto.GenerateKey()
+
+	database := ethdb.NewMemDatabase()
+	genesis := core.DeveloperDexconGenesisBlock(0,
+		[]*ecdsa.PublicKey{&notary.PublicKey}, crypto.PubkeyToAddress(owner.PublicKey))
+	genesis.Config.Dexcon.BlockGasLimit = gasLimit
+	genesis.GasLimit = gasLimit
+	for addr, account := range alloc {
+		if account.Staked == nil {
+			account.Staked = new(big.Int)
+		}
+		genesis.Alloc[addr] = account
+	}
+	blockchain, engine, gov, err := core.NewDexonChain(database, genesis)
+	if err != nil {
+		panic(err)
+	}
+	engine.SetGovStateFetcher(core.NewSoleNotaryGovStateFetcher(gov, &notary.PublicKey))
+
+	backend := &DexonSimulatedBackend{
+		SimulatedBackend: &SimulatedBackend{
+			database:   database,
+			blockchain: blockchain,
+			config:     genesis.Config,
+			events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
+		},
+		engine: engine,
+		gov:    gov,
+		notary: notary,
+		owner:  owner,
+	}
+	backend.rollback()
+	return backend
+}
+
+// Commit imports all the pending transactions as a single block and starts a
+// fresh new state.
+func (b *DexonSimulatedBackend) Commit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
+		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+	}
+	b.rollback()
+}
+
+// Rollback aborts all pending transactions, reverting to the last committed state.
+func (b *DexonSimulatedBackend) Rollback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollback()
+}
+
+func (b *DexonSimulatedBackend) rollback() {
+	b.generatePending(nil, 0)
+}
+
+// generatePending regenerates the pending block on top of the current one
+// with the given transactions, offsetting its time by offset milliseconds.
+func (b *DexonSimulatedBackend) generatePending(txs []*types.Transaction, offset int64) {
+	current := b.blockchain.CurrentBlock()
+	position := coreTypes.Position{
+		Round:  b.blockchain.NextBlockRound(),
+		Height: current.NumberU64() + 1,
+	}
+	blocks, _ := core.GenerateDexonChain(b.config, current, b.engine, b.database, 1, func(number int, block *core.DexonBlockGen) {
+		block.SetCoinbase(crypto.PubkeyToAddress(b.notary.PublicKey))
+		block.SetPosition(position)
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+		if offset != 0 {
+			block.OffsetTime(offset)
+		}
+	})
+	statedb, _ := b.blockchain.State()
+
+	b.pendingBlock = blocks[0]
+	b.pendingState, _ = state.New(b.pendingBlock.Root(), statedb.Database())
+}
+
+// SuggestGasPrice implements ContractTransactor.SuggestGasPrice, returning the
+// minimal gas price of the pending round.
+func (b *DexonSimulatedBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.gov.MinGasPrice(b.pendingBlock.Round()), nil
+}
+
+// SendTransaction updates the pending block to include the given transaction.
+// It panics if the transaction is invalid.
+func (b *DexonSimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sender, err := types.Sender(types.MakeSigner(b.config, b.pendingBlock.Number()), tx)
+	if err != nil {
+		panic(fmt.Errorf("invalid transaction: %v", err))
+	}
+	nonce := b.pendingState.GetNonce(sender)
+	if tx.Nonce() != nonce {
+		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
+	}
+	b.generatePending(append(b.pendingBlock.Transactions(), tx), 0)
+	return nil
+}
+
+// AdjustTime adds a time shift to the simulated clock.
+func (b *DexonSimulatedBackend) AdjustTime(adjustment time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.generatePending(b.pendingBlock.Transactions(), int64(adjustment/time.Millisecond))
+	return nil
+}
+
+// Round returns the round of the current block.
+func (b *DexonSimulatedBackend) Round() uint64 {
+	return b.blockchain.CurrentBlock().Round()
+}
+
+// AdvanceRound commits the pending transactions, followed by empty blocks
+// until the chain enters the next round, and returns the new round.
+func (b *DexonSimulatedBackend) AdvanceRound() uint64 {
+	round := b.Round()
+	for b.Round() == round {
+		b.Commit()
+	}
+	return b.Round()
+}
+
+// GovernanceState returns the governance state of the current block.
+func (b *DexonSimulatedBackend) GovernanceState() *vm.GovernanceState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.gov.GetHeadState()
+}
+
+// RegisterNode registers the node of key in the governance contract, staking
+// the given amount from its account.
+func (b *DexonSimulatedBackend) RegisterNode(key *ecdsa.PrivateKey, stake *big.Int) error {
+	input, err := vm.PackRegister(crypto.FromECDSAPub(&key.PublicKey), "Test Node", "test@dexon.org", "Taipei", "https://dexon.org")
+	if err != nil {
+		return err
+	}
+	return b.transactGovernance(key, stake, input)
+}
+
+// Stake stakes the given amount more to the node of key.
+func (b *DexonSimulatedBackend) Stake(key *ecdsa.PrivateKey, amount *big.Int) error {
+	input, err := vm.PackStake()
+	if err != nil {
+		return err
+	}
+	return b.transactGovernance(key, amount, input)
+}
+
+// FineNode fines the node of key by reporting two of its votes for a fork,
+// signed with key. The governance owner sends the report.
+func (b *DexonSimulatedBackend) FineNode(key *ecdsa.PrivateKey) error {
+	signer := coreUtils.NewSigner(coreEcdsa.NewPrivateKeyFromECDSA(key))
+
+	vote1 := coreTypes.NewVote(coreTypes.VoteCom, coreCommon.NewRandomHash(), 0)
+	vote2 := vote1.Clone()
+	for vote2.BlockHash == vote1.BlockHash {
+		vote2.BlockHash = coreCommon.NewRandomHash()
+	}
+	if err := signer.SignVote(vote1); err != nil {
+		return err
+	}
+	if err := signer.SignVote(vote2); err != nil {
+		return err
+	}
+	input, err := vm.PackReportForkVote(vote1, vote2)
+	if err != nil {
+		return err
+	}
+	return b.transactGovernance(b.owner, big.NewInt(0), input)
+}
+
+// transactGovernance calls the governance contract from the account of key
+// with value attached, and commits the transaction in a new block.
+func (b *DexonSimulatedBackend) transactGovernance(key *ecdsa.PrivateKey, value *big.Int, input []byte) error {
+	ctx := context.Background()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := vm.GovernanceContractAddress
+
+	gas, err := b.EstimateGas(ctx, dexon.CallMsg{From: from, To: &to, Value: value, Data: input})
+	if err != nil {
+		return err
+	}
+	gasPrice, _ := b.SuggestGasPrice(ctx)
+	nonce, _ := b.PendingNonceAt(ctx, from)
+
+	tx := types.NewTransaction(nonce, to, value, gas, gasPrice, input)
+	tx, err = types.SignTx(tx, types.NewEIP155Signer(b.config.ChainID), key)
+	if err != nil {
+		return err
+	}
+	if err := b.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.Commit()
+
+	receipt, _ := b.TransactionReceipt(ctx, tx.Hash())
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		return errGovernanceReverted
+	}
+	return nil
+}
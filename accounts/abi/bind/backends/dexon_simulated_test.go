@@ -0,0 +1,83 @@
+package backends
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/dexon-foundation/dexon"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+// Tests that test nodes can be registered, staked and fined through the
+// governance contract, which contract calls see, and that the chain moves
+// through the rounds with the notary rewarded.
+func TestDexonSimulatedBackendGovernance(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	funds := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e7))
+
+	sim := NewDexonSimulatedBackend(core.GenesisAlloc{addr: {Balance: funds}}, 10000000)
+	minStake := sim.GovernanceState().MinStake()
+
+	if err := sim.RegisterNode(key, minStake); err != nil {
+		t.Fatalf("Register node fail: %v", err)
+	}
+	if err := sim.Stake(key, minStake); err != nil {
+		t.Fatalf("Stake fail: %v", err)
+	}
+	gs := sim.GovernanceState()
+	offset := gs.NodesOffsetByAddress(addr)
+	if offset.Sign() < 0 {
+		t.Fatalf("node not registered")
+	}
+	if staked, want := gs.Node(offset).Staked, new(big.Int).Mul(minStake, big.NewInt(2)); staked.Cmp(want) != 0 {
+		t.Errorf("staked mismatch: have %v, want %v", staked, want)
+	}
+
+	// Contract calls read the same governance state
+	input, _ := vm.GovernanceABI.ABI.Pack("totalStaked")
+	res, err := sim.CallContract(context.Background(), dexon.CallMsg{To: &vm.GovernanceContractAddress, Data: input}, nil)
+	if err != nil {
+		t.Fatalf("Call governance fail: %v", err)
+	}
+	totalStaked := new(big.Int)
+	if err := vm.GovernanceABI.ABI.Unpack(&totalStaked, "totalStaked", res); err != nil {
+		t.Fatalf("Unpack fail: %v", err)
+	}
+	if totalStaked.Cmp(gs.TotalStaked()) != 0 {
+		t.Errorf("total staked mismatch: have %v, want %v", totalStaked, gs.TotalStaked())
+	}
+
+	if err := sim.FineNode(key); err != nil {
+		t.Fatalf("Fine node fail: %v", err)
+	}
+	gs = sim.GovernanceState()
+	if fined, want := gs.Node(offset).Fined, gs.FineValue(big.NewInt(vm.FineTypeForkVote)); fined.Cmp(want) != 0 {
+		t.Errorf("fined mismatch: have %v, want %v", fined, want)
+	}
+	// A fined node can't stake until the fine is paid
+	if err := sim.Stake(key, minStake); err == nil {
+		t.Errorf("fined node staked")
+	}
+
+	for round := uint64(1); round <= 3; round++ {
+		if have := sim.AdvanceRound(); have != round {
+			t.Fatalf("round mismatch: have %d, want %d", have, round)
+		}
+	}
+	current := sim.blockchain.CurrentBlock()
+	if current.Reward().Sign() <= 0 {
+		t.Errorf("notary not rewarded")
+	}
+	gs = sim.GovernanceState()
+	if height := gs.RoundHeight(big.NewInt(3)).Uint64(); height != current.NumberU64() {
+		t.Errorf("round height mismatch: have %d, want %d", height, current.NumberU64())
+	}
+	notary := crypto.PubkeyToAddress(sim.notary.PublicKey)
+	if len(gs.QualifiedNodes()) == 0 || gs.QualifiedNodes()[0].Owner != notary {
+		t.Errorf("notary disqualified")
+	}
+}
@@ -93,7 +93,15 @@ func loadConfig(file string, cfg *gethConfig) error {
 	if _, ok := err.(*toml.LineError); ok {
 		err = errors.New(file + ", " + err.Error())
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	// Fold the single recovery network endpoint of older config files.
+	if cfg.Dex.RecoveryNetworkRPC != "" {
+		cfg.Dex.RecoveryNetworkRPCs = append([]string{cfg.Dex.RecoveryNetworkRPC}, cfg.Dex.RecoveryNetworkRPCs...)
+		cfg.Dex.RecoveryNetworkRPC = ""
+	}
+	return nil
 }
 
 func defaultNodeConfig() node.Config {
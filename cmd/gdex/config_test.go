@@ -0,0 +1,36 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests that config files with the single recovery network endpoint written
+// before multiple endpoints were supported still load.
+func TestLoadConfigRecoveryNetworkRPC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gdex-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.toml")
+	content := "[Dex]\nRecoveryNetworkRPC = \"https://a.example\"\nRecoveryNetworkRPCs = [\"https://b.example\"]\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg gethConfig
+	if err := loadConfig(file, &cfg); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	want := []string{"https://a.example", "https://b.example"}
+	if !reflect.DeepEqual(cfg.Dex.RecoveryNetworkRPCs, want) {
+		t.Errorf("endpoints mismatch: have %v, want %v", cfg.Dex.RecoveryNetworkRPCs, want)
+	}
+	if cfg.Dex.RecoveryNetworkRPC != "" {
+		t.Errorf("deprecated endpoint not folded: %q", cfg.Dex.RecoveryNetworkRPC)
+	}
+}
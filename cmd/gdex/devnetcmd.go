@@ -0,0 +1,241 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/node"
+	"github.com/dexon-foundation/dexon/p2p/enode"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	devnetNodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "Number of block proposing nodes to run (at least 4)",
+		Value: 4,
+	}
+	devnetDelayFlag = cli.DurationFlag{
+		Name:  "delay",
+		Usage: "Time from now the consensus starts at",
+		Value: 15 * time.Second,
+	}
+	devnetCommand = cli.Command{
+		Action:   utils.MigrateFlags(devnet),
+		Name:     "devnet",
+		Usage:    "Run a local multi-node DEXON network",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+    gdex devnet --nodes 4
+
+writes a DEXON genesis with the validators staked and short consensus rounds,
+and runs the block proposing nodes in-process on localhost. The nodes listen on
+consecutive ports starting at --port and serve HTTP-RPC on consecutive ports
+starting at --rpcport.
+
+The node keys and a pre-funded faucet key are generated into the devnet folder
+of the data directory on first use and reused afterwards. The chains however
+start from a new genesis on every run, as a stopped network can't resume its
+consensus without reaching the recovery network.`,
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.ListenPortFlag,
+			utils.RPCPortFlag,
+			devnetNodesFlag,
+			devnetDelayFlag,
+		},
+	}
+)
+
+// devnet runs a local network of block proposing nodes from a new genesis.
+func devnet(ctx *cli.Context) error {
+	dir := filepath.Join(utils.MakeDataDir(ctx), "devnet")
+	n := ctx.Int(devnetNodesFlag.Name)
+	dMoment := time.Now().Add(ctx.Duration(devnetDelayFlag.Name))
+
+	// A lone notary confirms its block from its own votes before delivering
+	// it and then waits for a peer to send the block, while two or three nodes
+	// can't fill a notary set of four.
+	if n < 4 {
+		utils.Fatalf("A devnet needs at least 4 nodes, have %d", n)
+	}
+
+	keys, faucet, err := loadDevnetKeys(dir, n)
+	if err != nil {
+		utils.Fatalf("Failed to load devnet keys: %v", err)
+	}
+	genesis, err := makeDevnetGenesis(dir, keys, crypto.PubkeyToAddress(faucet.PublicKey), dMoment)
+	if err != nil {
+		utils.Fatalf("Failed to write devnet genesis: %v", err)
+	}
+	port := ctx.Int(utils.ListenPortFlag.Name)
+	rpcPort := ctx.Int(utils.RPCPortFlag.Name)
+
+	// Validators bootstrap off each other on the loopback interface
+	enodes := make([]*enode.Node, n)
+	for i, key := range keys {
+		enodes[i] = enode.NewV4(&key.PublicKey, net.IPv4(127, 0, 0, 1), port+i, port+i)
+	}
+	// Stop every started node on return, including when a later one fails to
+	// start.
+	stacks := make([]*node.Node, 0, n)
+	defer func() {
+		for _, stack := range stacks {
+			stack.Stop()
+		}
+	}()
+	for i, key := range keys {
+		cfg := defaultNodeConfig()
+		cfg.DataDir = filepath.Join(dir, fmt.Sprintf("node%d", i))
+		cfg.P2P.PrivateKey = key
+		cfg.P2P.ListenAddr = fmt.Sprintf("127.0.0.1:%d", port+i)
+		cfg.P2P.NAT = nil
+		cfg.P2P.BootstrapNodes = append(append([]*enode.Node{}, enodes[:i]...), enodes[i+1:]...)
+		cfg.HTTPHost = "127.0.0.1"
+		cfg.HTTPPort = rpcPort + i
+		cfg.HTTPModules = append(cfg.HTTPModules, "debug")
+
+		if err := os.RemoveAll(cfg.DataDir); err != nil {
+			return fmt.Errorf("failed to remove the chain of node %d: %v", i, err)
+		}
+		stack, err := node.New(&cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create node %d: %v", i, err)
+		}
+		dexCfg := dex.DefaultConfig
+		dexCfg.Genesis = genesis
+		dexCfg.NetworkId = genesis.Config.ChainID.Uint64()
+		dexCfg.BlockProposerEnabled = true
+		utils.RegisterDexService(stack, &dexCfg)
+
+		if err := stack.Start(); err != nil {
+			return fmt.Errorf("failed to start node %d: %v", i, err)
+		}
+		stacks = append(stacks, stack)
+	}
+
+	fmt.Println()
+	fmt.Printf("DEXON devnet of %d nodes, chain ID %v, consensus starting at %v\n",
+		n, genesis.Config.ChainID, dMoment.Format(time.RFC3339))
+	fmt.Printf("Faucet account %s, key %s\n\n",
+		crypto.PubkeyToAddress(faucet.PublicKey).Hex(), filepath.Join(dir, "keys", "faucet.key"))
+	for i, key := range keys {
+		fmt.Printf("node%d  %s  http://127.0.0.1:%d  %s\n",
+			i, crypto.PubkeyToAddress(key.PublicKey).Hex(), rpcPort+i, enodes[i])
+	}
+	fmt.Println()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	<-sigc
+	log.Info("Got interrupt, shutting down devnet...")
+	return nil
+}
+
+// loadDevnetKeys loads the node keys of n validators and the faucet key from
+// the devnet folder, generating the missing ones.
+func loadDevnetKeys(dir string, n int) ([]*ecdsa.PrivateKey, *ecdsa.PrivateKey, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "keys"), 0700); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		key, err := loadOrGenerateKey(filepath.Join(dir, "keys", fmt.Sprintf("node%d.key", i)))
+		if err != nil {
+			return nil, nil, err
+		}
+		keys[i] = key
+	}
+	faucet, err := loadOrGenerateKey(filepath.Join(dir, "keys", "faucet.key"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return keys, faucet, nil
+}
+
+// loadOrGenerateKey loads the key stored at path, or generates and stores a
+// new one if there is none.
+func loadOrGenerateKey(path string) (*ecdsa.PrivateKey, error) {
+	key, err := crypto.LoadECDSA(path)
+	if !os.IsNotExist(err) {
+		return key, err
+	}
+	if key, err = crypto.GenerateKey(); err != nil {
+		return nil, err
+	}
+	return key, crypto.SaveECDSA(path, key)
+}
+
+// makeDevnetGenesis assembles the genesis of a devnet staking the validators
+// of keys, and writes it into the devnet folder for reference.
+func makeDevnetGenesis(dir string, keys []*ecdsa.PrivateKey, faucet common.Address, dMoment time.Time) (*core.Genesis, error) {
+	pubkeys := make([]*ecdsa.PublicKey, len(keys))
+	for i, key := range keys {
+		pubkeys[i] = &key.PublicKey
+	}
+	genesis := core.DeveloperDexconGenesisBlock(uint64(dMoment.Unix()), pubkeys, faucet)
+	if err := checkDevnetGenesis(genesis, len(keys)); err != nil {
+		return nil, err
+	}
+	blob, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "genesis.json"), blob, 0644); err != nil {
+		return nil, err
+	}
+	return genesis, nil
+}
+
+// checkDevnetGenesis ensures that the n validators of a devnet genesis are
+// enough to fill the notary set, as the consensus would never confirm a block
+// otherwise.
+func checkDevnetGenesis(genesis *core.Genesis, n int) error {
+	db := ethdb.NewMemDatabase()
+	block := genesis.ToBlock(db)
+
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	gov := &vm.GovernanceState{StateDB: statedb}
+	if size := gov.NotarySetSize().Uint64(); size == 0 || size > uint64(n) {
+		return fmt.Errorf("%d nodes can't fill a notary set of %d", n, size)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dkgMonitorCommand = cli.Command{
+		Action:    utils.MigrateFlags(dkgMonitor),
+		Name:      "dkgmonitor",
+		Usage:     "Monitor notary set DKG participation",
+		ArgsUsage: "[<round>]",
+		Category:  "MONITOR COMMANDS",
+		Description: `
+The DKG monitor follows the chain of the attached node and reports, for the
+DKG of each round, which notary set members submitted their master public key,
+MPKReady, Finalize and Success messages, the complaints filed and how many
+times the DKG was reset.
+
+If a round is given, the DKG status of that round is printed once instead.`,
+		Flags: []cli.Flag{
+			monitorCommandAttachFlag,
+			monitorCommandRefreshFlag,
+		},
+	}
+)
+
+// dkgMonitor prints the DKG status of a given round, or follows the DKG of
+// upcoming rounds until interrupted.
+func dkgMonitor(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command accepts at most one argument.")
+	}
+	endpoint := ctx.String(monitorCommandAttachFlag.Name)
+	client, err := dialRPC(endpoint)
+	if err != nil {
+		utils.Fatalf("Unable to attach to gdex node: %v", err)
+	}
+	defer client.Close()
+
+	if len(ctx.Args()) == 1 {
+		round, err := strconv.ParseUint(ctx.Args().First(), 0, 64)
+		if err != nil {
+			utils.Fatalf("Invalid round %q: %v", ctx.Args().First(), err)
+		}
+		status, err := fetchDKGStatus(client, round)
+		if err != nil {
+			utils.Fatalf("Failed to retrieve DKG status: %v", err)
+		}
+		printDKGStatus(os.Stdout, status)
+		return nil
+	}
+
+	// The DKG of round r+1 runs during round r. Print its status whenever it
+	// changes, and the final status of a round once the chain reaches it.
+	var (
+		refresh = time.Duration(ctx.Int(monitorCommandRefreshFlag.Name)) * time.Second
+		printed = make(map[uint64][]byte)
+	)
+	for {
+		var current hexutil.Uint64
+		if err := client.Call(&current, "governance_getRound"); err != nil {
+			utils.Fatalf("Failed to retrieve current round: %v", err)
+		}
+		for _, round := range []uint64{uint64(current), uint64(current) + 1} {
+			status, err := fetchDKGStatus(client, round)
+			if err != nil {
+				// The DKG of the next round may not have started yet.
+				continue
+			}
+			var buf bytes.Buffer
+			printDKGStatus(&buf, status)
+			if !bytes.Equal(buf.Bytes(), printed[round]) {
+				fmt.Printf("%s\n", buf.Bytes())
+				printed[round] = buf.Bytes()
+			}
+		}
+		for round := range printed {
+			if round+1 < uint64(current) {
+				delete(printed, round)
+			}
+		}
+		time.Sleep(refresh)
+	}
+}
+
+func fetchDKGStatus(client *rpc.Client, round uint64) (*dex.GovernanceDKGStatus, error) {
+	status := new(dex.GovernanceDKGStatus)
+	err := client.Call(status, "governance_getDKGStatus", hexutil.Uint64(round))
+	return status, err
+}
+
+// printDKGStatus writes a human readable report of the DKG status.
+func printDKGStatus(out io.Writer, s *dex.GovernanceDKGStatus) {
+	n := len(s.Participants)
+	fmt.Fprintf(out, "Round %d DKG, reset %d times\n", s.Round, s.ResetCount)
+	fmt.Fprintf(out, "  MPK: %d/%d, MPKReady: %d/%d%s, Finalized: %d/%d%s, Success: %d/%d%s\n",
+		s.MPKCount, n,
+		s.MPKReadyCount, n, reached(s.MPKReady),
+		s.FinalizedCount, n, reached(s.Finalized),
+		s.SuccessCount, n, reached(s.Success))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NODE KEY ADDRESS\tOWNER\tMPK\tMPKREADY\tFINALIZED\tSUCCESS")
+	for _, p := range s.Participants {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", p.NodeKeyAddress.Hex(), p.Owner.Hex(),
+			yesNo(p.MPK), yesNo(p.MPKReady), yesNo(p.Finalized), yesNo(p.Success))
+	}
+	w.Flush()
+
+	if len(s.Complaints) == 0 {
+		fmt.Fprintln(out, "  No complaints")
+		return
+	}
+	fmt.Fprintln(out, "  Complaints:")
+	for _, c := range s.Complaints {
+		kind := "invalid share"
+		if c.IsNack {
+			kind = "nack"
+		}
+		fmt.Fprintf(out, "    %s -> %s (%s)\n", c.Proposer.Hex(), c.Target.Hex(), kind)
+	}
+}
+
+func reached(ok bool) string {
+	if ok {
+		return " (reached)"
+	}
+	return ""
+}
+
+func yesNo(ok bool) string {
+	if ok {
+		return "yes"
+	}
+	return "no"
+}
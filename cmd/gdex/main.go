@@ -94,10 +94,14 @@ var (
 		utils.CacheTrieFlag,
 		utils.CacheGCFlag,
 		utils.TrieCacheGenFlag,
+		utils.CacheConsensusFlag,
+		utils.CacheConsensusRoundsFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
 		utils.BlockProposerEnabledFlag,
+		utils.ConsensusRecordFlag,
+		utils.AggregatedAgreementFlag,
 		utils.MiningEnabledFlag,
 		utils.MinerThreadsFlag,
 		utils.MinerLegacyThreadsFlag,
@@ -140,7 +144,9 @@ var (
 		utils.IndexerEnableFlag,
 		utils.IndexerPluginFlag,
 		utils.IndexerPluginFlagsFlag,
+		utils.IndexerBuiltinFlag,
 		utils.RecoveryNetworkRPCFlag,
+		utils.RecoveryQuorumFlag,
 		configFileFlag,
 	}
 
@@ -193,6 +199,14 @@ func init() {
 		dumpCommand,
 		// See monitorcmd.go:
 		monitorCommand,
+		// See devnetcmd.go:
+		devnetCommand,
+		// See dkgmonitorcmd.go:
+		dkgMonitorCommand,
+		// See recoverycmd.go:
+		recoveryCommand,
+		// See replaycmd.go:
+		replayCommand,
 		// See accountcmd.go:
 		accountCommand,
 		walletCommand,
@@ -339,7 +353,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 	}()
 	// Start auxiliary services if enabled
-	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
+	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) {
 		// Mining only makes sense if a full Ethereum node is running
 		if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support mining")
@@ -0,0 +1,182 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	recoveryCommand = cli.Command{
+		Name:     "recovery",
+		Usage:    "Inspect and trigger the recovery of a stalled chain",
+		Category: "MONITOR COMMANDS",
+		Description: `
+When the consensus core stops delivering blocks while syncing, the watch cat
+votes on the recovery network for skipping the stalled block. Once more than
+half of the notary set voted, the node forces its consensus core to sync.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "status",
+				Usage:     "Print the recovery status of the attached node",
+				ArgsUsage: " ",
+				Action:    utils.MigrateFlags(recoveryStatus),
+				Flags: []cli.Flag{
+					monitorCommandAttachFlag,
+				},
+				Description: `
+    gdex recovery status
+
+prints the watch cat state, the health of the recovery network endpoints and,
+for each height proposed for skipping, our vote transaction and the notary
+votes tallied on the recovery network.`,
+			},
+			{
+				Name:      "propose",
+				Usage:     "Vote for skipping the block at the given height",
+				ArgsUsage: "<height>",
+				Action:    utils.MigrateFlags(recoveryPropose),
+				Flags: []cli.Flag{
+					monitorCommandAttachFlag,
+				},
+				Description: `
+    gdex recovery propose <height>
+
+makes the attached node vote on the recovery network for skipping the block
+at the given height, as the watch cat does, and prints the vote status.`,
+			},
+		},
+	}
+)
+
+func dialRecovery(ctx *cli.Context) *rpc.Client {
+	client, err := dialRPC(ctx.String(monitorCommandAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to gdex node: %v", err)
+	}
+	return client
+}
+
+func recoveryStatus(ctx *cli.Context) error {
+	client := dialRecovery(ctx)
+	defer client.Close()
+
+	status := new(dex.RecoveryStatus)
+	if err := client.Call(status, "admin_recoveryStatus"); err != nil {
+		utils.Fatalf("Failed to retrieve recovery status: %v", err)
+	}
+	printRecoveryStatus(os.Stdout, status)
+	return nil
+}
+
+func recoveryPropose(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a height argument.")
+	}
+	height, err := strconv.ParseUint(ctx.Args().First(), 0, 64)
+	if err != nil {
+		utils.Fatalf("Invalid height %q: %v", ctx.Args().First(), err)
+	}
+	client := dialRecovery(ctx)
+	defer client.Close()
+
+	status := new(dex.SkipBlockStatus)
+	if err := client.Call(status, "admin_proposeSkipBlock", hexutil.Uint64(height)); err != nil {
+		utils.Fatalf("Failed to propose skipping block %d: %v", height, err)
+	}
+	printSkipBlockStatus(os.Stdout, status)
+	return nil
+}
+
+// printRecoveryStatus writes a human readable report of the recovery status.
+func printRecoveryStatus(out io.Writer, s *dex.RecoveryStatus) {
+	fmt.Fprintln(out, "Watch cat:")
+	switch {
+	case s.WatchCat == nil:
+		fmt.Fprintln(out, "  unknown")
+	case s.WatchCat.Running:
+		fmt.Fprintf(out, "  running since %v\n", s.WatchCat.StartedAt.Format(time.RFC3339))
+	default:
+		fmt.Fprintln(out, "  not running")
+	}
+	if s.WatchCat != nil && s.WatchCat.LastRecovery != nil {
+		fmt.Fprintf(out, "  last recovered at %s\n", s.WatchCat.LastRecovery)
+	}
+
+	fmt.Fprintf(out, "\nRecovery network endpoints (quorum %d):\n", s.Quorum)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  URL\tHEALTHY\tFAILURES\tLAST ERROR")
+	for _, e := range s.Endpoints {
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%s\n", e.URL, yesNo(e.Healthy), e.Failures, e.LastError)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nSkip block votes (more than %d notary votes needed):\n", s.Threshold)
+	if len(s.SkipBlocks) == 0 {
+		fmt.Fprintln(out, "  None")
+	}
+	for _, b := range s.SkipBlocks {
+		printSkipBlockStatus(out, b)
+	}
+}
+
+func printSkipBlockStatus(out io.Writer, s *dex.SkipBlockStatus) {
+	by := "watch cat"
+	if s.Manual {
+		by = "operator"
+	}
+	fmt.Fprintf(out, "  Height %d, proposed by %s at %v\n", s.Height, by, s.ProposedAt.Format(time.RFC3339))
+	switch {
+	case s.TxHash != nil:
+		fmt.Fprintf(out, "    Vote tx:  %s (%s", s.TxHash.Hex(), s.TxStatus)
+		if s.TxBlock != nil {
+			fmt.Fprintf(out, " in block %d", *s.TxBlock)
+		}
+		fmt.Fprintln(out, ")")
+	case s.TxStatus != "":
+		fmt.Fprintf(out, "    Vote tx:  %s\n", s.TxStatus)
+	default:
+		fmt.Fprintln(out, "    Vote tx:  not sent")
+	}
+	if s.Error != "" {
+		fmt.Fprintf(out, "    Error:    %s\n", s.Error)
+	}
+	if s.VotesError != "" {
+		fmt.Fprintf(out, "    Votes:    unknown (%s)\n", s.VotesError)
+		return
+	}
+	if s.VotesCheckedAt.IsZero() {
+		fmt.Fprintln(out, "    Votes:    not tallied yet")
+		return
+	}
+	fmt.Fprintf(out, "    Votes:    %d%s\n", s.Votes, reached(s.Reached))
+	for _, voter := range s.Voters {
+		fmt.Fprintf(out, "      %s\n", voter.Hex())
+	}
+}
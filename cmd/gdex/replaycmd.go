@@ -0,0 +1,133 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/dex"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	replaySpeedFlag = cli.Float64Flag{
+		Name:  "speed",
+		Usage: "Replay speed relative to the recorded timing (0 = as fast as possible)",
+	}
+	replayOutboundFlag = cli.BoolFlag{
+		Name:  "outbound",
+		Usage: "Also print the recorded outbound messages",
+	}
+	replayCommand = cli.Command{
+		Action:    utils.MigrateFlags(replayConsensus),
+		Name:      "replay",
+		Usage:     "Replay recorded consensus messages",
+		ArgsUsage: "<recorddir>",
+		Category:  "MISCELLANEOUS COMMANDS",
+		Description: `
+    gdex replay <recorddir>
+
+replays the consensus messages recorded by a node running with --bp.record
+into a fake network, in the recorded order, and prints the messages delivered
+to the consensus core as they are delivered.`,
+		Flags: []cli.Flag{
+			replaySpeedFlag,
+			replayOutboundFlag,
+		},
+	}
+)
+
+func replayConsensus(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires a record directory argument.")
+	}
+	msgs, err := dex.ReadRecordedMsgs(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to read consensus record: %v", err)
+	}
+	if ctx.Bool(replayOutboundFlag.Name) {
+		printOutboundMsgs(os.Stdout, msgs)
+	}
+
+	network := dex.NewReplayNetwork(msgs)
+	done := make(chan error, 1)
+	go func() {
+		done <- network.Replay(ctx.Float64(replaySpeedFlag.Name), nil)
+	}()
+	start := time.Now()
+	delivered := 0
+loop:
+	for {
+		select {
+		case msg := <-network.ReceiveChan():
+			printReplayedMsg(os.Stdout, time.Since(start), msg)
+			delivered++
+		case err := <-done:
+			if err != nil {
+				utils.Fatalf("Failed to replay consensus record: %v", err)
+			}
+			break loop
+		}
+	}
+	// Print the messages delivered right before the replay ended.
+	for drained := false; !drained; {
+		select {
+		case msg := <-network.ReceiveChan():
+			printReplayedMsg(os.Stdout, time.Since(start), msg)
+			delivered++
+		default:
+			drained = true
+		}
+	}
+	fmt.Printf("Replayed %d messages delivering %d messages to the consensus core\n", len(msgs), delivered)
+	return nil
+}
+
+func printReplayedMsg(out io.Writer, elapsed time.Duration, msg types.Msg) {
+	fmt.Fprintf(out, "%12v  %-16.16v  %v\n", elapsed.Round(time.Millisecond), msg.PeerID, msg.Payload)
+}
+
+func printOutboundMsgs(out io.Writer, msgs []*dex.RecordedMsg) {
+	fmt.Fprintln(out, "Outbound messages:")
+	for _, msg := range msgs {
+		if !msg.Outbound {
+			continue
+		}
+		payloads, err := dex.DecodeRecordedMsg(msg)
+		if err != nil {
+			fmt.Fprintf(out, "  %v  %-16.16s  undecodable message %#x: %v\n",
+				time.Unix(0, int64(msg.Time)).Format(time.RFC3339Nano), msg.Peer, msg.Code, err)
+			continue
+		}
+		if len(payloads) == 0 {
+			fmt.Fprintf(out, "  %v  %-16.16s  pull request %#x\n",
+				time.Unix(0, int64(msg.Time)).Format(time.RFC3339Nano), msg.Peer, msg.Code)
+		}
+		for _, payload := range payloads {
+			fmt.Fprintf(out, "  %v  %-16.16s  %v\n",
+				time.Unix(0, int64(msg.Time)).Format(time.RFC3339Nano), msg.Peer, payload)
+		}
+	}
+	fmt.Fprintln(out)
+}
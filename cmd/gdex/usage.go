@@ -137,6 +137,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.CacheTrieFlag,
 			utils.CacheGCFlag,
 			utils.TrieCacheGenFlag,
+			utils.CacheConsensusFlag,
+			utils.CacheConsensusRoundsFlag,
 		},
 	},
 	{
@@ -189,6 +191,8 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "BLOCK PROPOSER",
 		Flags: []cli.Flag{
 			utils.BlockProposerEnabledFlag,
+			utils.ConsensusRecordFlag,
+			utils.AggregatedAgreementFlag,
 		},
 	},
 	{
@@ -246,6 +250,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IndexerEnableFlag,
 			utils.IndexerPluginFlag,
 			utils.IndexerPluginFlagsFlag,
+			utils.IndexerBuiltinFlag,
 		},
 	},
 	{
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	heightFlag = cli.Uint64Flag{
+		Name:  "height",
+		Usage: "block height to inspect (default: head block)",
+	}
+	roundFlag = cli.Uint64Flag{
+		Name:  "round",
+		Usage: "round to inspect, at the first block of the round",
+	}
+)
+
+var commandInspect = cli.Command{
+	Name:      "inspect",
+	Usage:     "inspect the governance state of a stopped node",
+	ArgsUsage: " ",
+	Description: `
+Open the chain database of a stopped node read-only and print the governance
+state at the head block, at the given --height, or at the first block of the
+given --round.`,
+	Flags: []cli.Flag{
+		utils.DataDirFlag,
+		heightFlag,
+		roundFlag,
+	},
+	Action: inspect,
+}
+
+func inspect(ctx *cli.Context) error {
+	if ctx.IsSet(heightFlag.Name) && ctx.IsSet(roundFlag.Name) {
+		utils.Fatalf("--%s and --%s are mutually exclusive", heightFlag.Name, roundFlag.Name)
+	}
+	path := filepath.Join(ctx.String(utils.DataDirFlag.Name), "gdex", "chaindata")
+	db, err := ethdb.NewReadOnlyLDBDatabase(path, 0, 0)
+	if err != nil {
+		utils.Fatalf("Failed to open database at %s: %v", path, err)
+	}
+	defer db.Close()
+
+	head := readHeader(db, rawdb.ReadHeadBlockHash(db))
+	if head == nil {
+		utils.Fatalf("Head block not found in %s", path)
+	}
+	header := head
+	switch {
+	case ctx.IsSet(heightFlag.Name):
+		header = readHeader(db, rawdb.ReadCanonicalHash(db, ctx.Uint64(heightFlag.Name)))
+		if header == nil {
+			utils.Fatalf("Block %d not found", ctx.Uint64(heightFlag.Name))
+		}
+	case ctx.IsSet(roundFlag.Name):
+		round := ctx.Uint64(roundFlag.Name)
+		if round > head.Round {
+			utils.Fatalf("Round %d not reached, head block is in round %d", round, head.Round)
+		}
+		headState := openGovernanceState(db, head)
+		height := headState.RoundHeight(new(big.Int).SetUint64(round)).Uint64()
+		if round != 0 && height == 0 {
+			utils.Fatalf("Height of round %d not recorded", round)
+		}
+		header = readHeader(db, rawdb.ReadCanonicalHash(db, height))
+		if header == nil {
+			utils.Fatalf("Block %d not found", height)
+		}
+	}
+	printGovernanceState(header, openGovernanceState(db, header))
+	return nil
+}
+
+func readHeader(db ethdb.Database, hash common.Hash) *types.Header {
+	number := rawdb.ReadHeaderNumber(db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadHeader(db, hash, *number)
+}
+
+func openGovernanceState(db ethdb.Database, header *types.Header) *vm.GovernanceState {
+	statedb, err := state.New(header.Root, state.NewDatabase(db))
+	if err != nil {
+		utils.Fatalf("State of block %d not available: %v", header.Number, err)
+	}
+	return &vm.GovernanceState{StateDB: statedb}
+}
+
+func printGovernanceState(header *types.Header, s *vm.GovernanceState) {
+	fmt.Printf("Block:               %d (%x)\n", header.Number, header.Hash())
+	fmt.Printf("Round:               %d\n", header.Round)
+	fmt.Printf("Owner:               %s\n", s.Owner().Hex())
+	fmt.Printf("Total supply:        %v\n", s.TotalSupply())
+	fmt.Printf("Total staked:        %v\n", s.TotalStaked())
+	fmt.Printf("Mining velocity:     %v\n", s.Configuration().MiningVelocity)
+	fmt.Printf("Next halving supply: %v\n", s.NextHalvingSupply())
+	fmt.Printf("Last halved amount:  %v\n", s.LastHalvedAmount())
+
+	fmt.Println("\nRound heights:")
+	for round := uint64(0); round <= header.Round; round++ {
+		height := s.RoundHeight(new(big.Int).SetUint64(round))
+		if round != 0 && height.Sign() == 0 {
+			break
+		}
+		fmt.Printf("  %d: %v\n", round, height)
+	}
+
+	cfg := s.Configuration()
+	fmt.Println("\nConfiguration:")
+	fmt.Printf("  Min stake:          %v\n", cfg.MinStake)
+	fmt.Printf("  Lockup period:      %d\n", cfg.LockupPeriod)
+	fmt.Printf("  Min gas price:      %v\n", cfg.MinGasPrice)
+	fmt.Printf("  Block gas limit:    %d\n", cfg.BlockGasLimit)
+	fmt.Printf("  Lambda BA:          %d\n", cfg.LambdaBA)
+	fmt.Printf("  Lambda DKG:         %d\n", cfg.LambdaDKG)
+	fmt.Printf("  Notary set size:    %d\n", cfg.NotarySetSize)
+	fmt.Printf("  Notary param alpha: %v\n", cfg.NotaryParamAlpha)
+	fmt.Printf("  Notary param beta:  %v\n", cfg.NotaryParamBeta)
+	fmt.Printf("  Round length:       %d\n", cfg.RoundLength)
+	fmt.Printf("  Min block interval: %d\n", cfg.MinBlockInterval)
+	fmt.Printf("  Fine values:        %v\n", cfg.FineValues)
+
+	fmt.Println("\nNodes:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  OWNER\tNODE KEY ADDRESS\tSTAKED\tFINED\tUNSTAKED\tUNSTAKED AT\tLAST PROPOSED\tNAME")
+	for _, n := range s.Nodes() {
+		var nodeKeyAddress string
+		if pk, err := crypto.UnmarshalPubkey(n.PublicKey); err == nil {
+			nodeKeyAddress = crypto.PubkeyToAddress(*pk).Hex()
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%v\t%v\t%v\t%v\t%v\t%s\n", n.Owner.Hex(), nodeKeyAddress,
+			n.Staked, n.Fined, n.Unstaked, n.UnstakedAt, s.LastProposedHeight(n.Owner), n.Name)
+	}
+	w.Flush()
+
+	dkgRound := s.DKGRound()
+	fmt.Println("\nCRS:")
+	fmt.Printf("  Round: %v\n", s.CRSRound())
+	fmt.Printf("  CRS:   %x\n", s.CRS())
+
+	fmt.Println("\nDKG:")
+	fmt.Printf("  Round:       %v\n", dkgRound)
+	fmt.Printf("  Reset count: %v\n", s.DKGResetCount(dkgRound))
+	fmt.Printf("  MPKReady:    %v\n", s.DKGMPKReadysCount())
+	fmt.Printf("  Finalized:   %v\n", s.DKGFinalizedsCount())
+	fmt.Printf("  Success:     %v\n", s.DKGSuccessesCount())
+	mpks := s.DKGMasterPublicKeyItems()
+	fmt.Printf("  Master public keys (%d):\n", len(mpks))
+	for _, mpk := range mpks {
+		fmt.Printf("    %s (reset %d)\n", vm.IdToAddress(mpk.ProposerID).Hex(), mpk.Reset)
+	}
+	complaints := s.DKGComplaintItems()
+	fmt.Printf("  Complaints (%d):\n", len(complaints))
+	for _, c := range complaints {
+		kind := "invalid share"
+		if c.IsNack() {
+			kind = "nack"
+		}
+		fmt.Printf("    %s -> %s (%s, reset %d)\n", vm.IdToAddress(c.ProposerID).Hex(),
+			vm.IdToAddress(c.PrivateShare.ProposerID).Hex(), kind, c.Reset)
+	}
+}
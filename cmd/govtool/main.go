@@ -23,6 +23,17 @@ func init() {
 	app = utils.NewApp(gitCommit, "DEXON governance tool")
 	app.Commands = []cli.Command{
 		commandDecodeInput,
+		commandRegister,
+		commandStake,
+		commandUnstake,
+		commandWithdraw,
+		commandPayFine,
+		commandTransferOwnership,
+		commandTransferNodeOwnership,
+		commandReplaceNodePublicKey,
+		commandUpdateConfiguration,
+		commandReport,
+		commandInspect,
 	}
 }
 
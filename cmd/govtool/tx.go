@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"github.com/dexon-foundation/dexon"
+	"github.com/dexon-foundation/dexon/accounts/keystore"
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/common/math"
+	"github.com/dexon-foundation/dexon/console"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethclient"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	keyFileFlag = cli.StringFlag{
+		Name:  "keyfile",
+		Usage: "keystore file of the signing key",
+	}
+	passwordFileFlag = cli.StringFlag{
+		Name:  "passwordfile",
+		Usage: "file containing the password of the keystore file",
+	}
+	rawKeyFlag = cli.StringFlag{
+		Name:  "rawkey",
+		Usage: "file containing the hex encoded signing key",
+	}
+	rpcFlag = cli.StringFlag{
+		Name:  "rpc",
+		Usage: "endpoint of the node to send the transaction to",
+	}
+	chainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "chain ID used to sign the transaction (default: chain ID of the node)",
+	}
+	nonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "nonce of the transaction (default: pending nonce of the sender)",
+	}
+	gasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "gas price in wei (default: gas price suggested by the node)",
+	}
+	gasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "gas limit of the transaction (default: intrinsic gas plus governance action cost)",
+	}
+	valueFlag = cli.StringFlag{
+		Name:  "value",
+		Usage: "amount in wei sent along with the call",
+	}
+
+	govTxFlags = []cli.Flag{
+		keyFileFlag,
+		passwordFileFlag,
+		rawKeyFlag,
+		rpcFlag,
+		chainIDFlag,
+		nonceFlag,
+		gasPriceFlag,
+		gasFlag,
+	}
+)
+
+// newGovTxCommand creates a command calling a governance contract method with
+// the input built by pack.
+//
+// Without a signing key the command prints the call input. With a key it
+// prints the signed transaction, or sends it if a node endpoint is given.
+func newGovTxCommand(name, usage, argsUsage string, payable bool,
+	pack func(ctx *cli.Context) ([]byte, error)) cli.Command {
+	flags := govTxFlags
+	if payable {
+		flags = append([]cli.Flag{valueFlag}, flags...)
+	}
+	return cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: argsUsage,
+		Description: `
+Without --keyfile or --rawkey the governance call input is printed. With a
+signing key the signed transaction is printed, or sent if --rpc is given.`,
+		Flags: flags,
+		Action: func(ctx *cli.Context) error {
+			data, err := pack(ctx)
+			if err != nil {
+				utils.Fatalf("Failed to pack input: %v", err)
+			}
+			return sendGovTx(ctx, data)
+		},
+	}
+}
+
+var (
+	commandRegister = newGovTxCommand("register", "register a node",
+		"<public-key> <name> <email> <location> <url>", true,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 5)
+			return vm.PackRegister(parseBytes(ctx.Args()[0]), ctx.Args()[1],
+				ctx.Args()[2], ctx.Args()[3], ctx.Args()[4])
+		})
+	commandStake = newGovTxCommand("stake", "stake to the node of the sender", "", true,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 0)
+			return vm.PackStake()
+		})
+	commandUnstake = newGovTxCommand("unstake", "unstake from the node of the sender",
+		"<amount>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			return vm.PackUnstake(parseAmount(ctx.Args()[0]))
+		})
+	commandWithdraw = newGovTxCommand("withdraw", "withdraw unstaked amount after the lockup period", "", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 0)
+			return vm.PackWithdraw()
+		})
+	commandPayFine = newGovTxCommand("pay-fine", "pay the fine of a node", "<node-address>", true,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			return vm.PackPayFine(parseAddress(ctx.Args()[0]))
+		})
+	commandTransferOwnership = newGovTxCommand("transfer-ownership",
+		"transfer the ownership of the governance contract", "<new-owner>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			return vm.PackTransferOwnership(parseAddress(ctx.Args()[0]))
+		})
+	commandTransferNodeOwnership = newGovTxCommand("transfer-node-ownership",
+		"transfer the ownership of the node of the sender", "<new-owner>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			return vm.PackTransferNodeOwnership(parseAddress(ctx.Args()[0]))
+		})
+	commandReplaceNodePublicKey = newGovTxCommand("replace-node-public-key",
+		"replace the public key of the node of the sender", "<public-key>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			return vm.PackReplaceNodePublicKey(parseBytes(ctx.Args()[0]))
+		})
+	commandUpdateConfiguration = newGovTxCommand("update-configuration",
+		"update the governance configuration", "<config.json>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 1)
+			content, err := ioutil.ReadFile(ctx.Args()[0])
+			if err != nil {
+				return nil, err
+			}
+			cfg := new(params.DexconConfig)
+			if err := json.Unmarshal(content, cfg); err != nil {
+				return nil, err
+			}
+			return vm.PackUpdateConfiguration(cfg)
+		})
+	commandReport = newGovTxCommand("report", "report a misbehaving node",
+		"<fork-vote|fork-block> <rlp-hex-1> <rlp-hex-2>", false,
+		func(ctx *cli.Context) ([]byte, error) {
+			checkArgs(ctx, 3)
+			var fineType uint64
+			switch ctx.Args()[0] {
+			case "fork-vote":
+				fineType = vm.FineTypeForkVote
+			case "fork-block":
+				fineType = vm.FineTypeForkBlock
+			default:
+				utils.Fatalf("unknown report type %q", ctx.Args()[0])
+			}
+			return vm.PackReport(fineType, parseBytes(ctx.Args()[1]), parseBytes(ctx.Args()[2]))
+		})
+)
+
+// sendGovTx prints the input, prints the signed transaction or sends the
+// transaction calling the governance contract, depending on the flags given.
+func sendGovTx(ctx *cli.Context, data []byte) error {
+	key := loadKey(ctx)
+	if key == nil {
+		fmt.Println(hexutil.Encode(data))
+		return nil
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	value := new(big.Int)
+	if ctx.IsSet(valueFlag.Name) {
+		value = parseAmount(ctx.String(valueFlag.Name))
+	}
+
+	var client *ethclient.Client
+	if endpoint := ctx.String(rpcFlag.Name); endpoint != "" {
+		var err error
+		if client, err = ethclient.Dial(endpoint); err != nil {
+			utils.Fatalf("Failed to connect to %s: %v", endpoint, err)
+		}
+		defer client.Close()
+	}
+
+	// Never fall back to a default chain ID, a transaction meant for a
+	// testnet would be valid on the mainnet otherwise.
+	chainID := new(big.Int).SetUint64(ctx.Uint64(chainIDFlag.Name))
+	if client != nil {
+		remote, err := client.ChainID(context.Background())
+		if err != nil {
+			utils.Fatalf("Failed to retrieve chain ID: %v", err)
+		}
+		if ctx.IsSet(chainIDFlag.Name) && remote.Cmp(chainID) != 0 {
+			utils.Fatalf("Chain ID %v mismatches chain ID %v of the node", chainID, remote)
+		}
+		chainID = remote
+	} else if !ctx.IsSet(chainIDFlag.Name) {
+		utils.Fatalf("--%s is required without --%s", chainIDFlag.Name, rpcFlag.Name)
+	}
+
+	nonce := ctx.Uint64(nonceFlag.Name)
+	if !ctx.IsSet(nonceFlag.Name) {
+		if client == nil {
+			utils.Fatalf("--%s is required without --%s", nonceFlag.Name, rpcFlag.Name)
+		}
+		var err error
+		if nonce, err = client.PendingNonceAt(context.Background(), from); err != nil {
+			utils.Fatalf("Failed to retrieve nonce: %v", err)
+		}
+	}
+	var gasPrice *big.Int
+	if ctx.IsSet(gasPriceFlag.Name) {
+		gasPrice = parseAmount(ctx.String(gasPriceFlag.Name))
+	} else {
+		if client == nil {
+			utils.Fatalf("--%s is required without --%s", gasPriceFlag.Name, rpcFlag.Name)
+		}
+		var err error
+		if gasPrice, err = client.SuggestGasPrice(context.Background()); err != nil {
+			utils.Fatalf("Failed to retrieve gas price: %v", err)
+		}
+	}
+	gas := ctx.Uint64(gasFlag.Name)
+	if !ctx.IsSet(gasFlag.Name) {
+		intrinsic, err := core.IntrinsicGas(data, false, false)
+		if err != nil {
+			utils.Fatalf("Failed to compute intrinsic gas: %v", err)
+		}
+		gas = intrinsic + vm.GovernanceActionGasCost
+	}
+
+	tx := types.NewTransaction(nonce, vm.GovernanceContractAddress, value, gas, gasPrice, data)
+	signer := types.NewEIP155Signer(chainID)
+	tx, err := types.SignTx(tx, signer, key)
+	if err != nil {
+		utils.Fatalf("Failed to sign transaction: %v", err)
+	}
+
+	if client == nil {
+		raw, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			utils.Fatalf("Failed to encode transaction: %v", err)
+		}
+		fmt.Println(hexutil.Encode(raw))
+		return nil
+	}
+	// Catch reverting calls before paying for them.
+	msg := dexon.CallMsg{From: from, To: &vm.GovernanceContractAddress,
+		Gas: gas, GasPrice: gasPrice, Value: value, Data: data}
+	if _, err := client.PendingCallContract(context.Background(), msg); err != nil {
+		utils.Fatalf("Governance call would fail: %v", err)
+	}
+	if err := client.SendTransaction(context.Background(), tx); err != nil {
+		utils.Fatalf("Failed to send transaction: %v", err)
+	}
+	fmt.Println(tx.Hash().Hex())
+	return nil
+}
+
+// loadKey loads the signing key from a keystore or a raw key file. It returns
+// nil if neither is given.
+func loadKey(ctx *cli.Context) *ecdsa.PrivateKey {
+	keyFile, rawKeyFile := ctx.String(keyFileFlag.Name), ctx.String(rawKeyFlag.Name)
+	switch {
+	case keyFile != "" && rawKeyFile != "":
+		utils.Fatalf("--%s and --%s are mutually exclusive", keyFileFlag.Name, rawKeyFlag.Name)
+	case rawKeyFile != "":
+		key, err := crypto.LoadECDSA(rawKeyFile)
+		if err != nil {
+			utils.Fatalf("Failed to load key: %v", err)
+		}
+		return key
+	case keyFile != "":
+		keyjson, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			utils.Fatalf("Failed to read the keyfile at '%s': %v", keyFile, err)
+		}
+		var password string
+		if passwordFile := ctx.String(passwordFileFlag.Name); passwordFile != "" {
+			content, err := ioutil.ReadFile(passwordFile)
+			if err != nil {
+				utils.Fatalf("Failed to read password file '%s': %v", passwordFile, err)
+			}
+			password = strings.TrimRight(string(content), "\r\n")
+		} else if password, err = console.Stdin.PromptPassword("Password: "); err != nil {
+			utils.Fatalf("Failed to read password: %v", err)
+		}
+		key, err := keystore.DecryptKey(keyjson, password)
+		if err != nil {
+			utils.Fatalf("Error decrypting key: %v", err)
+		}
+		return key.PrivateKey
+	}
+	return nil
+}
+
+func checkArgs(ctx *cli.Context, n int) {
+	if len(ctx.Args()) != n {
+		utils.Fatalf("%s expects %d arguments, got %d", ctx.Command.Name, n, len(ctx.Args()))
+	}
+}
+
+func parseBytes(s string) []byte {
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		utils.Fatalf("Invalid hex data %q: %v", s, err)
+	}
+	return b
+}
+
+func parseAddress(s string) common.Address {
+	if !common.IsHexAddress(s) {
+		utils.Fatalf("Invalid address %q", s)
+	}
+	return common.HexToAddress(s)
+}
+
+func parseAmount(s string) *big.Int {
+	amount, ok := math.ParseBig256(s)
+	if !ok {
+		utils.Fatalf("Invalid amount %q", s)
+	}
+	return amount
+}
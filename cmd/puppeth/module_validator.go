@@ -0,0 +1,235 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/log"
+)
+
+// validatorDockerfile is the Dockerfile required to run a DEXON validator.
+var validatorDockerfile = `
+FROM dexonfoundation/dexon:latest
+
+ADD genesis.json /genesis.json
+ADD nodekey /nodekey
+
+RUN \
+  echo 'gdex --cache 512 init /genesis.json' > gdex.sh && \
+	echo $'exec gdex --bp --networkid {{.NetworkID}} --cache 512 --nodekey /nodekey --port {{.Port}} --nat extip:{{.IP}} --maxpeers {{.Peers}} {{if .Ethstats}}--ethstats \'{{.Ethstats}}\'{{end}} {{if .Bootnodes}}--bootnodes {{.Bootnodes}}{{end}} --recovery.network-rpc {{.RecoveryRPC}}' >> gdex.sh
+
+ENTRYPOINT ["/bin/sh", "gdex.sh"]
+`
+
+// validatorComposefile is the docker-compose.yml file required to deploy and
+// maintain a DEXON validator.
+var validatorComposefile = `
+version: '2'
+services:
+  {{.Type}}:
+    build: .
+    image: {{.Network}}/{{.Type}}
+    container_name: {{.Network}}_{{.Type}}_1
+    ports:
+      - "{{.Port}}:{{.Port}}"
+      - "{{.Port}}:{{.Port}}/udp"
+    volumes:
+      - {{.Datadir}}:/root/.dexon
+    environment:
+      - PORT={{.Port}}/tcp
+      - TOTAL_PEERS={{.TotalPeers}}
+      - STATS_NAME={{.Ethstats}}
+      - RECOVERY_RPC={{.RecoveryRPC}}
+    logging:
+      driver: "json-file"
+      options:
+        max-size: "1m"
+        max-file: "10"
+    restart: always
+`
+
+// validatorService returns the name of the service running a validator.
+func validatorService(index int) string {
+	return fmt.Sprintf("validator%d", index)
+}
+
+// deployValidator deploys a new DEXON validator container to a remote machine
+// via SSH, docker and docker-compose. If an instance with the specified network
+// name and index already exists there, it will be overwritten!
+func deployValidator(client *sshClient, network string, bootnodes []string, config *validatorInfos, nocache bool) ([]byte, error) {
+	kind := validatorService(config.index)
+
+	// Generate the content to upload to the server
+	workdir := fmt.Sprintf("%d", rand.Int63())
+	files := make(map[string][]byte)
+
+	ethstats := ""
+	if config.ethstats != "" {
+		ethstats = config.ethstats[:strings.Index(config.ethstats, ":")]
+	}
+	dockerfile := new(bytes.Buffer)
+	template.Must(template.New("").Parse(validatorDockerfile)).Execute(dockerfile, map[string]interface{}{
+		"NetworkID":   config.network,
+		"Port":        config.port,
+		"IP":          client.address,
+		"Peers":       config.peersTotal,
+		"Bootnodes":   strings.Join(bootnodes, ","),
+		"Ethstats":    config.ethstats,
+		"RecoveryRPC": config.recoveryRPC,
+	})
+	files[filepath.Join(workdir, "Dockerfile")] = dockerfile.Bytes()
+
+	composefile := new(bytes.Buffer)
+	template.Must(template.New("").Parse(validatorComposefile)).Execute(composefile, map[string]interface{}{
+		"Type":        kind,
+		"Datadir":     config.datadir,
+		"Network":     network,
+		"Port":        config.port,
+		"TotalPeers":  config.peersTotal,
+		"Ethstats":    ethstats,
+		"RecoveryRPC": config.recoveryRPC,
+	})
+	files[filepath.Join(workdir, "docker-compose.yaml")] = composefile.Bytes()
+
+	files[filepath.Join(workdir, "genesis.json")] = config.genesis
+	files[filepath.Join(workdir, "nodekey")] = []byte(config.nodeKey)
+
+	// Upload the deployment files to the remote server (and clean up afterwards)
+	if out, err := client.Upload(files); err != nil {
+		return out, err
+	}
+	defer client.Run("rm -rf " + workdir)
+
+	// Build and deploy the validator service
+	if nocache {
+		return nil, client.Stream(fmt.Sprintf("cd %s && docker-compose -p %s build --pull --no-cache && docker-compose -p %s up -d --force-recreate --timeout 60", workdir, network, network))
+	}
+	return nil, client.Stream(fmt.Sprintf("cd %s && docker-compose -p %s up -d --build --force-recreate --timeout 60", workdir, network))
+}
+
+// validatorInfos is returned from a validator status check to allow reporting
+// various configuration parameters.
+type validatorInfos struct {
+	genesis     []byte
+	network     int64
+	index       int
+	datadir     string
+	ethstats    string
+	port        int
+	enode       string
+	peersTotal  int
+	nodeKey     string
+	recoveryRPC string
+}
+
+// Report converts the typed struct into a plain string->string map, containing
+// most - but not all - fields for reporting to the user.
+func (info *validatorInfos) Report() map[string]string {
+	report := map[string]string{
+		"Data directory":         info.datadir,
+		"Listener port":          strconv.Itoa(info.port),
+		"Peer count (all total)": strconv.Itoa(info.peersTotal),
+		"Ethstats username":      info.ethstats,
+		"Recovery network RPC":   info.recoveryRPC,
+	}
+	if key, err := crypto.HexToECDSA(info.nodeKey); err == nil {
+		report["Validator account"] = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	} else {
+		log.Error("Failed to retrieve validator address", "err", err)
+	}
+	return report
+}
+
+// listValidators returns the indexes of the validators deployed on a server,
+// running or not.
+func listValidators(client *sshClient, network string) ([]int, error) {
+	out, err := client.Run(fmt.Sprintf("docker ps -a --filter name=%s_validator --format '{{.Names}}'", network))
+	if err != nil {
+		return nil, err
+	}
+	var indexes []int
+	for _, name := range strings.Fields(string(out)) {
+		var index int
+		if _, err := fmt.Sscanf(name, network+"_validator%d_1", &index); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes, nil
+}
+
+// checkValidator does a health-check against a validator server to verify
+// whether it's running, and if yes, whether it's responsive.
+func checkValidator(client *sshClient, network string, index int) (*validatorInfos, error) {
+	container := fmt.Sprintf("%s_%s_1", network, validatorService(index))
+
+	infos, err := inspectContainer(client, container)
+	if err != nil {
+		return nil, err
+	}
+	if !infos.running {
+		return nil, ErrServiceOffline
+	}
+	// Resolve a few types from the environmental variables
+	totalPeers, _ := strconv.Atoi(infos.envvars["TOTAL_PEERS"])
+
+	// Container available, retrieve its enode, its genesis json and its key
+	var out []byte
+	if out, err = client.Run(fmt.Sprintf("docker exec %s gdex --exec admin.nodeInfo.enode --cache=16 attach", container)); err != nil {
+		return nil, ErrServiceUnreachable
+	}
+	enode := bytes.Trim(bytes.TrimSpace(out), "\"")
+
+	if out, err = client.Run(fmt.Sprintf("docker exec %s cat /genesis.json", container)); err != nil {
+		return nil, ErrServiceUnreachable
+	}
+	genesis := bytes.TrimSpace(out)
+
+	if out, err = client.Run(fmt.Sprintf("docker exec %s cat /nodekey", container)); err != nil {
+		return nil, ErrServiceUnreachable
+	}
+	nodeKey := string(bytes.TrimSpace(out))
+
+	// Run a sanity check to see if the devp2p is reachable
+	port := infos.portmap[infos.envvars["PORT"]]
+	if err = checkPort(client.server, port); err != nil {
+		log.Warn("Validator devp2p port seems unreachable", "server", client.server, "port", port, "err", err)
+	}
+	// Assemble and return the useful infos
+	stats := &validatorInfos{
+		genesis:     genesis,
+		index:       index,
+		datadir:     infos.volumes["/root/.dexon"],
+		port:        port,
+		enode:       string(enode),
+		peersTotal:  totalPeers,
+		ethstats:    infos.envvars["STATS_NAME"],
+		nodeKey:     nodeKey,
+		recoveryRPC: infos.envvars["RECOVERY_RPC"],
+	}
+	return stats, nil
+}
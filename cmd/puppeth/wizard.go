@@ -18,6 +18,8 @@ package main
 
 import (
 	"bufio"
+	"crypto/ecdsa"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -33,6 +35,7 @@ import (
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/log"
 	"golang.org/x/crypto/ssh/terminal"
 )
@@ -329,6 +332,35 @@ func (w *wizard) readDefaultAddress(def common.Address) common.Address {
 	}
 }
 
+// readPublicKey reads a single line from stdin, trimming if from spaces and
+// converts it to a secp256k1 public key. If an empty line is entered, nil is
+// returned.
+func (w *wizard) readPublicKey() *ecdsa.PublicKey {
+	for {
+		// Read the public key from the user
+		fmt.Printf("> 0x")
+		text, err := w.in.ReadString('\n')
+		if err != nil {
+			log.Crit("Failed to read user input", "err", err)
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			return nil
+		}
+		// Make sure it looks ok and return it if so
+		blob, err := hex.DecodeString(text)
+		if err != nil {
+			log.Error("Invalid public key encoding, please retry", "err", err)
+			continue
+		}
+		pubkey, err := crypto.UnmarshalPubkey(blob)
+		if err != nil {
+			log.Error("Invalid public key, please retry", "err", err)
+			continue
+		}
+		return pubkey
+	}
+}
+
 // readJSON reads a raw JSON message and returns it.
 func (w *wizard) readJSON() string {
 	var blob json.RawMessage
@@ -0,0 +1,281 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+var (
+	dxn  = big.NewInt(params.Ether)
+	gwei = big.NewInt(params.GWei)
+)
+
+// fineDescriptions describes the offenses fined by the governance contract,
+// indexed by fine type.
+var fineDescriptions = []string{
+	vm.FineTypeFailStop:    "failing to propose blocks",
+	vm.FineTypeFailStopDKG: "failing to take part in the DKG",
+	vm.FineTypeInvalidDKG:  "sending invalid DKG messages",
+	vm.FineTypeForkVote:    "voting for forks",
+	vm.FineTypeForkBlock:   "proposing forks",
+}
+
+// validatorKeyFile returns the name of the file the node key of a generated
+// validator is saved into.
+func validatorKeyFile(network string, index int) string {
+	return fmt.Sprintf("%s-validator%d.key", network, index)
+}
+
+// makeDexconGenesis configures the DEXON consensus parameters of a genesis,
+// along with its initial validators, whose node keys are either generated or
+// imported.
+func (w *wizard) makeDexconGenesis(genesis *core.Genesis) {
+	// Start from the testnet parameters, with all forks enabled from genesis
+	chainConfig := *params.TestnetChainConfig
+	dexcon := *chainConfig.Dexcon
+	dexcon.FineValues = make([]*big.Int, len(chainConfig.Dexcon.FineValues))
+	copy(dexcon.FineValues, chainConfig.Dexcon.FineValues)
+	recovery := *chainConfig.Recovery
+	chainConfig.Dexcon, chainConfig.Recovery = &dexcon, &recovery
+
+	genesis.Config = &chainConfig
+	genesis.Difficulty = big.NewInt(1)
+
+	fmt.Println()
+	fmt.Printf("Which text should the genesis CRS be derived from? (default = %q)\n", dexcon.GenesisCRSText)
+	dexcon.GenesisCRSText = w.readDefaultString(dexcon.GenesisCRSText)
+
+	fmt.Println()
+	fmt.Println("Which account should own the governance contract? (mandatory)")
+	for {
+		if address := w.readAddress(); address != nil {
+			dexcon.Owner = *address
+			break
+		}
+	}
+	fmt.Println()
+	fmt.Println("How many minutes from now should the consensus start? (default = 10)")
+	start := time.Now().Add(time.Duration(w.readDefaultInt(10)) * time.Minute)
+	chainConfig.DMoment = uint64(start.Unix())
+	genesis.Timestamp = chainConfig.DMoment * 1000
+
+	// Query the round and agreement timings
+	fmt.Println()
+	fmt.Printf("How many blocks should a round last? (default = %d)\n", dexcon.RoundLength)
+	dexcon.RoundLength = uint64(w.readDefaultInt(int(dexcon.RoundLength)))
+
+	fmt.Println()
+	fmt.Printf("How many milliseconds should blocks take at least? (default = %d)\n", dexcon.MinBlockInterval)
+	dexcon.MinBlockInterval = uint64(w.readDefaultInt(int(dexcon.MinBlockInterval)))
+
+	fmt.Println()
+	fmt.Printf("What should the lambda of the Byzantine agreement be in milliseconds? (default = %d)\n", dexcon.LambdaBA)
+	dexcon.LambdaBA = uint64(w.readDefaultInt(int(dexcon.LambdaBA)))
+
+	fmt.Println()
+	fmt.Printf("What should the lambda of the DKG be in milliseconds? (default = %d)\n", dexcon.LambdaDKG)
+	dexcon.LambdaDKG = uint64(w.readDefaultInt(int(dexcon.LambdaDKG)))
+
+	fmt.Println()
+	fmt.Printf("What should the alpha parameter of the notary set size be? (default = %v)\n", dexcon.NotaryParamAlpha)
+	dexcon.NotaryParamAlpha = float32(w.readDefaultFloat(float64(dexcon.NotaryParamAlpha)))
+
+	fmt.Println()
+	fmt.Printf("What should the beta parameter of the notary set size be? (default = %v)\n", dexcon.NotaryParamBeta)
+	dexcon.NotaryParamBeta = float32(w.readDefaultFloat(float64(dexcon.NotaryParamBeta)))
+
+	// Query the staking and the economics of the network
+	fmt.Println()
+	fmt.Printf("How much should validators stake at least (DXN)? (default = %v)\n", new(big.Int).Div(dexcon.MinStake, dxn))
+	dexcon.MinStake = w.readDefaultDXN(dexcon.MinStake)
+
+	fmt.Println()
+	fmt.Printf("How many seconds should unstaked funds stay locked up? (default = %d)\n", dexcon.LockupPeriod/1000)
+	dexcon.LockupPeriod = uint64(w.readDefaultInt(int(dexcon.LockupPeriod/1000))) * 1000
+
+	fmt.Println()
+	fmt.Printf("What should the mining velocity of the block reward be? (default = %v)\n", dexcon.MiningVelocity)
+	dexcon.MiningVelocity = float32(w.readDefaultFloat(float64(dexcon.MiningVelocity)))
+
+	fmt.Println()
+	fmt.Printf("At which total supply should the block reward halve first (DXN)? (default = %v)\n", new(big.Int).Div(dexcon.NextHalvingSupply, dxn))
+	dexcon.NextHalvingSupply = w.readDefaultDXN(dexcon.NextHalvingSupply)
+
+	fmt.Println()
+	fmt.Printf("How much should the halving supply grow by, halved at each halving (DXN)? (default = %v)\n", new(big.Int).Div(dexcon.LastHalvedAmount, dxn))
+	dexcon.LastHalvedAmount = w.readDefaultDXN(dexcon.LastHalvedAmount)
+
+	fmt.Println()
+	fmt.Printf("What gas price should transactions pay at least (GWei)? (default = %v)\n", new(big.Int).Div(dexcon.MinGasPrice, gwei))
+	dexcon.MinGasPrice = new(big.Int).Mul(w.readDefaultBigInt(new(big.Int).Div(dexcon.MinGasPrice, gwei)), gwei)
+
+	fmt.Println()
+	fmt.Printf("What gas limit should blocks have? (default = %d)\n", dexcon.BlockGasLimit)
+	dexcon.BlockGasLimit = uint64(w.readDefaultInt(int(dexcon.BlockGasLimit)))
+	genesis.GasLimit = dexcon.BlockGasLimit
+
+	for i, description := range fineDescriptions {
+		fmt.Println()
+		fmt.Printf("How much should validators be fined for %s (DXN)? (default = %v)\n", description, new(big.Int).Div(dexcon.FineValues[i], dxn))
+		dexcon.FineValues[i] = w.readDefaultDXN(dexcon.FineValues[i])
+	}
+	// Query the recovery of stalled networks
+	fmt.Println()
+	fmt.Printf("Which contract should collect the recovery votes? (default = %s)\n", recovery.Contract.Hex())
+	recovery.Contract = w.readDefaultAddress(recovery.Contract)
+
+	fmt.Println()
+	fmt.Printf("How many seconds without blocks should trigger a recovery? (default = %d)\n", recovery.Timeout)
+	recovery.Timeout = w.readDefaultInt(recovery.Timeout)
+
+	fmt.Println()
+	fmt.Printf("How many confirmations should recovery votes wait for? (default = %d)\n", recovery.Confirmation)
+	recovery.Confirmation = w.readDefaultInt(recovery.Confirmation)
+
+	// Consensus all set, gather the initial validators
+	fmt.Println()
+	fmt.Printf("How much should each validator stake (DXN)? (default = %v)\n", new(big.Int).Div(dexcon.MinStake, dxn))
+	stake := w.readDefaultDXN(dexcon.MinStake)
+
+	fmt.Println()
+	fmt.Println("How many validator node keys should be generated? (default = 4)")
+	count := w.readDefaultInt(4)
+
+	var pubkeys []*ecdsa.PublicKey
+	if count > 0 {
+		fmt.Println()
+		fmt.Println("Which folder to save the validator node keys into? (default = current)")
+		folder := w.readDefaultString(".")
+		if err := os.MkdirAll(folder, 0700); err != nil {
+			log.Error("Failed to create key folder", "folder", folder, "err", err)
+			return
+		}
+		for i := 0; i < count; i++ {
+			key, err := crypto.GenerateKey()
+			if err != nil {
+				log.Error("Failed to generate node key", "err", err)
+				return
+			}
+			path := filepath.Join(folder, validatorKeyFile(w.network, i))
+			if err := crypto.SaveECDSA(path, key); err != nil {
+				log.Error("Failed to save node key", "path", path, "err", err)
+				return
+			}
+			log.Info("Saved validator node key", "address", crypto.PubkeyToAddress(key.PublicKey), "path", path)
+			pubkeys = append(pubkeys, &key.PublicKey)
+		}
+	}
+	fmt.Println()
+	fmt.Println("Which existing nodes should validate too? (node public keys, mandatory at least one validator)")
+	for {
+		if pubkey := w.readPublicKey(); pubkey != nil {
+			pubkeys = append(pubkeys, pubkey)
+			continue
+		}
+		if len(pubkeys) > 0 {
+			break
+		}
+	}
+	for i, pubkey := range pubkeys {
+		genesis.Alloc[crypto.PubkeyToAddress(*pubkey)] = core.GenesisAccount{
+			// Leave validators as much to spend as they stake
+			Balance:   new(big.Int).Mul(stake, big.NewInt(2)),
+			Staked:    stake,
+			PublicKey: crypto.FromECDSAPub(pubkey),
+			NodeInfo: core.NodeInfo{
+				Name: fmt.Sprintf("%s validator %d", w.network, i),
+			},
+		}
+	}
+}
+
+// readDefaultDXN reads an amount of DXN from stdin, returning it in wei. If an
+// empty line is entered, the default value is returned.
+func (w *wizard) readDefaultDXN(def *big.Int) *big.Int {
+	if amount := w.readDefaultBigInt(nil); amount != nil {
+		return new(big.Int).Mul(amount, dxn)
+	}
+	return def
+}
+
+// validateDexconGenesis checks that a DEXON genesis initializes the governance
+// contract, by creating the genesis state the same way gdex init does, and
+// that the initial validators make up a notary set.
+func validateDexconGenesis(genesis *core.Genesis) (err error) {
+	config := genesis.Config.Dexcon
+	if config == nil {
+		return errors.New("missing dexcon configuration")
+	}
+	if genesis.Config.Recovery == nil {
+		return errors.New("missing recovery configuration")
+	}
+	if len(config.FineValues) != len(fineDescriptions) {
+		return fmt.Errorf("invalid fine value count: have %d, want %d", len(config.FineValues), len(fineDescriptions))
+	}
+	for addr, account := range genesis.Alloc {
+		if account.Balance == nil || account.Staked == nil {
+			return fmt.Errorf("account %x: missing balance or staked amount", addr)
+		}
+		if account.Staked.Cmp(account.Balance) > 0 {
+			return fmt.Errorf("account %x: staked amount exceeds balance", addr)
+		}
+		if account.Staked.Sign() > 0 {
+			if _, err := crypto.UnmarshalPubkey(account.PublicKey); err != nil {
+				return fmt.Errorf("validator %x: invalid public key: %v", addr, err)
+			}
+		}
+	}
+	// The governance contract panics on invalid genesis configurations
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("governance initialization failed: %v", r)
+		}
+	}()
+	db := ethdb.NewMemDatabase()
+	block := genesis.ToBlock(db)
+
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	gov := &vm.GovernanceState{StateDB: statedb}
+
+	qualified := uint64(len(gov.QualifiedNodes()))
+	if qualified == 0 {
+		return errors.New("no validator stakes the minimum stake")
+	}
+	if size := gov.NotarySetSize().Uint64(); size == 0 || size > qualified {
+		return fmt.Errorf("invalid notary set size %d for %d validators", size, qualified)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+// Tests that DEXON genesis specs are validated against the governance contract.
+func TestValidateDexconGenesis(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(genesis *core.Genesis)
+		valid  bool
+	}{
+		{"testnet", func(genesis *core.Genesis) {}, true},
+		{"missing fine values", func(genesis *core.Genesis) {
+			genesis.Config.Dexcon.FineValues = genesis.Config.Dexcon.FineValues[:1]
+		}, false},
+		{"supply over halving supply", func(genesis *core.Genesis) {
+			genesis.Config.Dexcon.NextHalvingSupply = big.NewInt(1)
+		}, false},
+		{"validators under min stake", func(genesis *core.Genesis) {
+			genesis.Config.Dexcon.MinStake = new(big.Int).Lsh(big.NewInt(1), 128)
+		}, false},
+		{"stake over balance", func(genesis *core.Genesis) {
+			for address, account := range genesis.Alloc {
+				if account.Staked.Sign() > 0 {
+					account.Balance = new(big.Int).Sub(account.Staked, big.NewInt(1))
+					genesis.Alloc[address] = account
+					break
+				}
+			}
+		}, false},
+	}
+	for _, tt := range tests {
+		genesis := core.DefaultTestnetGenesisBlock()
+		config := *genesis.Config
+		dexcon := *config.Dexcon
+		config.Dexcon, genesis.Config = &dexcon, &config
+
+		tt.modify(genesis)
+		if err := validateDexconGenesis(genesis); (err == nil) != tt.valid {
+			t.Errorf("%s: validity mismatch: have %v, want %v (err %v)", tt.name, err == nil, tt.valid, err)
+		}
+	}
+}
+
+// Tests that the genesis wizard creates a valid DEXON genesis, generating the
+// node keys of the validators.
+func TestMakeDexconGenesis(t *testing.T) {
+	dir, err := ioutil.TempDir("", "puppeth-")
+	if err != nil {
+		t.Fatalf("failed to create temporary folder: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	owner := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	funded := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	input := []string{
+		"3",              // consensus engine
+		"",               // genesis CRS text
+		owner.Hex()[2:],  // governance owner
+		"",               // consensus start
+		"",               // round length
+		"",               // min block interval
+		"",               // BA lambda
+		"",               // DKG lambda
+		"",               // notary set size alpha
+		"",               // notary set size beta
+		"",               // min stake
+		"",               // lockup period
+		"",               // mining velocity
+		"",               // next halving supply
+		"",               // last halved amount
+		"",               // min gas price
+		"",               // block gas limit
+		"",               // fail stop fine
+		"",               // DKG fail stop fine
+		"",               // invalid DKG fine
+		"",               // fork vote fine
+		"",               // fork block fine
+		"",               // recovery contract
+		"",               // recovery timeout
+		"",               // recovery confirmations
+		"",               // validator stake
+		"",               // generated validator count
+		dir,              // node key folder
+		"",               // imported validators
+		"",               // pre-fund balance
+		funded.Hex()[2:], // pre-funded account
+		"",               // no more pre-funded accounts
+		"",               // pre-fund precompiles
+		"1234",           // chain ID
+	}
+	w := &wizard{
+		network: "test",
+		conf:    config{path: filepath.Join(dir, "config")},
+		in:      bufio.NewReader(strings.NewReader(strings.Join(input, "\n") + "\n")),
+	}
+	w.makeGenesis()
+
+	genesis := w.conf.Genesis
+	if genesis == nil {
+		t.Fatalf("no genesis created")
+	}
+	if genesis.Config.Dexcon == nil || genesis.Config.Dexcon.Owner != owner {
+		t.Fatalf("dexcon configuration mismatch: %v", genesis.Config.Dexcon)
+	}
+	if genesis.Config.ChainID.Uint64() != 1234 {
+		t.Errorf("chain ID mismatch: have %v, want 1234", genesis.Config.ChainID)
+	}
+	if genesis.Timestamp != genesis.Config.DMoment*1000 {
+		t.Errorf("genesis timestamp %d not at consensus start %d", genesis.Timestamp, genesis.Config.DMoment)
+	}
+	want := new(big.Int).Mul(big.NewInt(1e6), dxn)
+	if balance := genesis.Alloc[funded].Balance; balance == nil || balance.Cmp(want) != 0 {
+		t.Errorf("pre-funded balance mismatch: have %v, want %v", balance, want)
+	}
+	for i := 0; i < 4; i++ {
+		key, err := crypto.LoadECDSA(filepath.Join(dir, validatorKeyFile(w.network, i)))
+		if err != nil {
+			t.Fatalf("validator %d: failed to load node key: %v", i, err)
+		}
+		account := genesis.Alloc[crypto.PubkeyToAddress(key.PublicKey)]
+		if account.Staked == nil || account.Staked.Cmp(genesis.Config.Dexcon.MinStake) != 0 {
+			t.Errorf("validator %d: staked amount mismatch: have %v, want %v", i, account.Staked, genesis.Config.Dexcon.MinStake)
+		}
+	}
+	if err := validateDexconGenesis(genesis); err != nil {
+		t.Errorf("invalid genesis created: %v", err)
+	}
+}
@@ -57,6 +57,7 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Which consensus engine to use? (default = clique)")
 	fmt.Println(" 1. Ethash - proof-of-work")
 	fmt.Println(" 2. Clique - proof-of-authority")
+	fmt.Println(" 3. Dexcon - delegated proof-of-stake")
 
 	choice := w.read()
 	switch {
@@ -103,17 +104,28 @@ func (w *wizard) makeGenesis() {
 			copy(genesis.ExtraData[32+i*common.AddressLength:], signer[:])
 		}
 
+	case choice == "3":
+		// In the case of dexcon, configure the consensus parameters and validators
+		w.makeDexconGenesis(genesis)
+
 	default:
 		log.Crit("Invalid consensus engine choice", "choice", choice)
 	}
 	// Consensus all set, just ask for initial funds and go
+	balance := new(big.Int).Lsh(big.NewInt(1), 256-7) // 2^256 / 128 (allow many pre-funds without balance overflows)
+	if genesis.Config.Dexcon != nil {
+		// The total supply of DEXON must stay below the next halving supply
+		fmt.Println()
+		fmt.Println("How much should pre-funded accounts hold (DXN)? (default = 1000000)")
+		balance = w.readDefaultDXN(new(big.Int).Mul(big.NewInt(1e6), dxn))
+	}
 	fmt.Println()
 	fmt.Println("Which accounts should be pre-funded? (advisable at least one)")
 	for {
 		// Read the address of the account to fund
 		if address := w.readAddress(); address != nil {
 			genesis.Alloc[*address] = core.GenesisAccount{
-				Balance: new(big.Int).Lsh(big.NewInt(1), 256-7), // 2^256 / 128 (allow many pre-funds without balance overflows)
+				Balance: balance,
 			}
 			continue
 		}
@@ -132,6 +144,20 @@ func (w *wizard) makeGenesis() {
 	fmt.Println("Specify your chain/network ID if you want an explicit one (default = random)")
 	genesis.Config.ChainID = new(big.Int).SetUint64(uint64(w.readDefaultInt(rand.Intn(65536))))
 
+	if genesis.Config.Dexcon != nil {
+		// Plain pre-funds stake nothing, then make sure the governance contract
+		// accepts the genesis
+		for address, account := range genesis.Alloc {
+			if account.Staked == nil {
+				account.Staked = new(big.Int)
+				genesis.Alloc[address] = account
+			}
+		}
+		if err := validateDexconGenesis(genesis); err != nil {
+			log.Error("Invalid DEXON genesis", "err", err)
+			return
+		}
+	}
 	// All done, store the genesis and flush to disk
 	log.Info("Configured new genesis block")
 
@@ -180,6 +206,12 @@ func (w *wizard) importGenesis() {
 		log.Error("Invalid genesis spec: %v", err)
 		return
 	}
+	if genesis.Config != nil && genesis.Config.Dexcon != nil {
+		if err := validateDexconGenesis(&genesis); err != nil {
+			log.Error("Invalid DEXON genesis", "err", err)
+			return
+		}
+	}
 	log.Info("Imported genesis block")
 
 	w.conf.Genesis = &genesis
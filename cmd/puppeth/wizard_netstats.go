@@ -133,6 +133,20 @@ func (w *wizard) gatherStats(server string, pubkey []byte, client *sshClient) *s
 		stat.services["sealnode"] = infos.Report()
 		genesis = string(infos.genesis)
 	}
+	logger.Debug("Checking for validator availability")
+	if indexes, err := listValidators(client, w.network); err == nil {
+		for _, index := range indexes {
+			service := validatorService(index)
+			if infos, err := checkValidator(client, w.network, index); err != nil {
+				stat.services[service] = map[string]string{"offline": err.Error()}
+			} else {
+				stat.services[service] = infos.Report()
+
+				genesis = string(infos.genesis)
+				bootnodes = append(bootnodes, infos.enode)
+			}
+		}
+	}
 	logger.Debug("Checking for explorer availability")
 	if infos, err := checkExplorer(client, w.network); err != nil {
 		if err != ErrServiceUnknown {
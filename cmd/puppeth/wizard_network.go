@@ -178,6 +178,7 @@ func (w *wizard) deployComponent() {
 	fmt.Println(" 5. Wallet    - Browser wallet for quick sends")
 	fmt.Println(" 6. Faucet    - Crypto faucet to give away funds")
 	fmt.Println(" 7. Dashboard - Website listing above web-services")
+	fmt.Println(" 8. Validators - DEXON block proposers (dexcon only)")
 
 	switch w.read() {
 	case "1":
@@ -194,6 +195,8 @@ func (w *wizard) deployComponent() {
 		w.deployFaucet()
 	case "7":
 		w.deployDashboard()
+	case "8":
+		w.deployValidators()
 	default:
 		log.Error("That's not something I can do")
 	}
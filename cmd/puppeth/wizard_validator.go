@@ -0,0 +1,170 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/p2p/enode"
+)
+
+// deployValidators deploys the validators of a DEXON genesis whose node keys
+// were generated by the genesis wizard onto a single server, bootstrapping a
+// small testnet.
+func (w *wizard) deployValidators() {
+	// Do some sanity check before the user wastes time on input
+	if w.conf.Genesis == nil {
+		log.Error("No genesis block configured")
+		return
+	}
+	if w.conf.Genesis.Config.Dexcon == nil {
+		log.Error("Validators require a DEXON genesis")
+		return
+	}
+	// Load the node keys of the validators from the disk
+	fmt.Println()
+	fmt.Println("Which folder holds the validator node keys? (default = current)")
+	folder := w.readDefaultString(".")
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; ; i++ {
+		path := filepath.Join(folder, validatorKeyFile(w.network, i))
+		key, err := crypto.LoadECDSA(path)
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			log.Error("Failed to load validator node key", "path", path, "err", err)
+			return
+		}
+		address := crypto.PubkeyToAddress(key.PublicKey)
+		if account, ok := w.conf.Genesis.Alloc[address]; !ok || account.Staked == nil || account.Staked.Sign() == 0 {
+			log.Error("Node key is not one of a genesis validator", "path", path, "address", address)
+			return
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		log.Error("No validator node keys found", "folder", folder)
+		return
+	}
+	// Select the server to interact with
+	server := w.selectServer()
+	if server == "" {
+		return
+	}
+	client := w.servers[server]
+
+	// Validators can't bootstrap a network whose consensus started already
+	if dMoment := time.Unix(int64(w.conf.Genesis.Config.DMoment), 0); dMoment.Before(time.Now()) {
+		if len(w.services) > 0 {
+			log.Warn("Consensus start time passed with network components running", "dMoment", dMoment)
+		} else {
+			fmt.Println()
+			fmt.Println("The consensus start time passed, how many minutes from now should it start? (default = 10)")
+			start := time.Now().Add(time.Duration(w.readDefaultInt(10)) * time.Minute)
+			w.conf.Genesis.Config.DMoment = uint64(start.Unix())
+			w.conf.Genesis.Timestamp = w.conf.Genesis.Config.DMoment * 1000
+			w.conf.flush()
+		}
+	}
+	genesis, _ := json.MarshalIndent(w.conf.Genesis, "", "  ")
+
+	// Figure out where the user wants to store the persistent data
+	fmt.Println()
+	fmt.Printf("Where should data be stored on the remote machine? (validators use numbered subfolders)\n")
+	datadir := w.readString()
+
+	// Figure out which ports to listen on
+	fmt.Println()
+	fmt.Printf("Which TCP/UDP port should the first validator listen on? (default = %d)\n", 30303)
+	port := w.readDefaultInt(30303)
+
+	fmt.Println()
+	fmt.Printf("How many peers to allow connecting? (default = %d)\n", 50)
+	peers := w.readDefaultInt(50)
+
+	// Set proper names to report on the stats page, if there is one
+	ethstats := ""
+	if w.conf.ethstats != "" {
+		fmt.Println()
+		fmt.Printf("What should the validators be called on the stats page? (default = validator)\n")
+		ethstats = w.readDefaultString("validator")
+	}
+	fmt.Println()
+	fmt.Printf("Which recovery network RPC endpoints should validators use? (default = https://rinkeby.infura.io)\n")
+	recoveryRPC := w.readDefaultString("https://rinkeby.infura.io")
+
+	// Validators bootstrap off each other along with the known bootnodes
+	enodes := make([]string, len(keys))
+	for i, key := range keys {
+		enodes[i] = enode.NewV4(&key.PublicKey, net.ParseIP(client.address), port+i, port+i).String()
+	}
+	existed := false
+	for i := range keys {
+		if _, err := checkValidator(client, w.network, i); err == nil {
+			existed = true
+		}
+	}
+	nocache := false
+	if existed {
+		fmt.Println()
+		fmt.Printf("Should the validators be built from scratch (y/n)? (default = no)\n")
+		nocache = w.readDefaultYesNo(false)
+	}
+	for i, key := range keys {
+		infos := &validatorInfos{
+			genesis:     genesis,
+			network:     w.conf.Genesis.Config.ChainID.Int64(),
+			index:       i,
+			datadir:     filepath.Join(datadir, validatorService(i)),
+			port:        port + i,
+			peersTotal:  peers,
+			nodeKey:     hex.EncodeToString(crypto.FromECDSA(key)),
+			recoveryRPC: recoveryRPC,
+		}
+		if ethstats != "" {
+			infos.ethstats = fmt.Sprintf("%s-%d:%s", ethstats, i, w.conf.ethstats)
+		}
+		bootnodes := append([]string{}, w.conf.bootnodes...)
+		bootnodes = append(bootnodes, enodes[:i]...)
+		bootnodes = append(bootnodes, enodes[i+1:]...)
+
+		if out, err := deployValidator(client, w.network, bootnodes, infos, nocache); err != nil {
+			log.Error("Failed to deploy validator container", "index", i, "err", err)
+			if len(out) > 0 {
+				fmt.Printf("%s\n", out)
+			}
+			return
+		}
+	}
+	// All ok, run a network scan to pick any changes up
+	log.Info("Waiting for validators to finish booting")
+	time.Sleep(3 * time.Second)
+
+	w.networkStats()
+}
@@ -46,6 +46,7 @@ import (
 	"github.com/dexon-foundation/dexon/eth/gasprice"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/ethstats"
+	"github.com/dexon-foundation/dexon/indexer"
 	"github.com/dexon-foundation/dexon/les"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/metrics"
@@ -151,11 +152,11 @@ var (
 	}
 	DeveloperFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Ephemeral proof-of-authority network with a pre-funded developer account, mining enabled",
+		Usage: "Ephemeral single-node DEXON network with a pre-funded developer account, block sealing enabled",
 	}
 	DeveloperPeriodFlag = cli.IntFlag{
 		Name:  "dev.period",
-		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
+		Usage: "Block period to use in developer mode (0 = seal only if transaction pending)",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -320,6 +321,15 @@ var (
 		Usage: "Percentage of cache memory allowance to use for trie pruning",
 		Value: 25,
 	}
+	CacheConsensusFlag = cli.IntFlag{
+		Name:  "cache.consensus",
+		Usage: "Megabytes of memory allocated to recent consensus votes and blocks",
+		Value: dex.DefaultConfig.ConsensusCache,
+	}
+	CacheConsensusRoundsFlag = cli.Uint64Flag{
+		Name:  "cache.consensus.rounds",
+		Usage: "Number of recent rounds of consensus votes and finalized blocks persisted across restarts, within --cache.consensus megabytes (0 = disabled)",
+	}
 	TrieCacheGenFlag = cli.IntFlag{
 		Name:  "trie-cache-gens",
 		Usage: "Number of trie node generations to keep in memory",
@@ -330,6 +340,14 @@ var (
 		Name:  "bp",
 		Usage: "Enable block proposer mode (node set)",
 	}
+	ConsensusRecordFlag = DirectoryFlag{
+		Name:  "bp.record",
+		Usage: "Directory to record the consensus messages exchanged with peers to",
+	}
+	AggregatedAgreementFlag = cli.BoolFlag{
+		Name:  "bp.aggregate",
+		Usage: "Relay agreement results with their threshold signature instead of the full vote list",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -664,13 +682,23 @@ var (
 		Usage: "External indexer plugin's flags if needed",
 		Value: "",
 	}
+	IndexerBuiltinFlag = cli.StringFlag{
+		Name:  "indexer.builtin",
+		Usage: "Comma separated list of built-in indexers to run (" + strings.Join(indexer.BuiltinNames(), ", ") + ")",
+		Value: "",
+	}
 
 	// Dexcon settings.
 	RecoveryNetworkRPCFlag = cli.StringFlag{
 		Name:  "recovery.network-rpc",
-		Usage: "RPC URL of the recovery network",
+		Usage: "Comma separated RPC URLs of the recovery network",
 		Value: "https://mainnet.infura.io",
 	}
+	RecoveryQuorumFlag = cli.IntFlag{
+		Name:  "recovery.quorum",
+		Usage: "Number of recovery network RPC endpoints that must agree on the votes (0 = majority)",
+		Value: 0,
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -816,6 +844,18 @@ func splitAndTrim(input string) []string {
 	return result
 }
 
+// splitAndTrimNonEmpty splits input separated by a comma, trims excessive
+// white space from the substrings and drops the empty ones.
+func splitAndTrimNonEmpty(input string) []string {
+	var result []string
+	for _, r := range splitAndTrim(input) {
+		if r != "" {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
 // setHTTP creates the HTTP RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func setHTTP(ctx *cli.Context, cfg *node.Config) {
@@ -1229,11 +1269,23 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 	if ctx.GlobalIsSet(BlockProposerEnabledFlag.Name) {
 		cfg.BlockProposerEnabled = ctx.GlobalBool(BlockProposerEnabledFlag.Name)
 	}
+	if ctx.GlobalIsSet(ConsensusRecordFlag.Name) {
+		cfg.ConsensusRecordDir = ctx.GlobalString(ConsensusRecordFlag.Name)
+	}
+	if ctx.GlobalIsSet(AggregatedAgreementFlag.Name) {
+		cfg.AggregatedAgreement = ctx.GlobalBool(AggregatedAgreementFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheDatabaseFlag.Name) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(CacheConsensusFlag.Name) {
+		cfg.ConsensusCache = ctx.GlobalInt(CacheConsensusFlag.Name)
+	}
+	if ctx.GlobalIsSet(CacheConsensusRoundsFlag.Name) {
+		cfg.ConsensusCacheRounds = ctx.GlobalUint64(CacheConsensusRoundsFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1265,8 +1317,15 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 		cfg.RPCGasCap = new(big.Int).SetUint64(ctx.GlobalUint64(RPCGlobalGasCap.Name))
 	}
 
-	cfg.RecoveryNetworkRPC = ctx.GlobalString(RecoveryNetworkRPCFlag.Name)
-	defaultRecoveryNetworkRPC := "https://rinkeby.infura.io"
+	// Endpoints of the config file are kept unless overridden by the flag.
+	recoveryNetworkRPCSet := ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) || len(cfg.RecoveryNetworkRPCs) > 0
+	if ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) || len(cfg.RecoveryNetworkRPCs) == 0 {
+		cfg.RecoveryNetworkRPCs = splitAndTrimNonEmpty(ctx.GlobalString(RecoveryNetworkRPCFlag.Name))
+	}
+	if ctx.GlobalIsSet(RecoveryQuorumFlag.Name) {
+		cfg.RecoveryQuorum = ctx.GlobalInt(RecoveryQuorumFlag.Name)
+	}
+	defaultRecoveryNetworkRPCs := []string{"https://rinkeby.infura.io"}
 
 	// Override any default configs for hard coded networks.
 	switch {
@@ -1274,33 +1333,30 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			cfg.NetworkId = 238
 		}
-		if !ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) {
-			cfg.RecoveryNetworkRPC = defaultRecoveryNetworkRPC
+		if !recoveryNetworkRPCSet {
+			cfg.RecoveryNetworkRPCs = defaultRecoveryNetworkRPCs
 		}
 		cfg.Genesis = core.DefaultTestnetGenesisBlock()
 	case ctx.GlobalBool(TaipeiFlag.Name):
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			cfg.NetworkId = 239
 		}
-		if !ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) {
-			cfg.RecoveryNetworkRPC = defaultRecoveryNetworkRPC
+		if !recoveryNetworkRPCSet {
+			cfg.RecoveryNetworkRPCs = defaultRecoveryNetworkRPCs
 		}
 		cfg.Genesis = core.DefaultTaipeiGenesisBlock()
 	case ctx.GlobalBool(YilanFlag.Name):
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			cfg.NetworkId = 240
 		}
-		if !ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) {
-			cfg.RecoveryNetworkRPC = defaultRecoveryNetworkRPC
+		if !recoveryNetworkRPCSet {
+			cfg.RecoveryNetworkRPCs = defaultRecoveryNetworkRPCs
 		}
 		cfg.Genesis = core.DefaultYilanGenesisBlock()
 	case ctx.GlobalBool(DeveloperFlag.Name):
 		if !ctx.GlobalIsSet(NetworkIdFlag.Name) {
 			cfg.NetworkId = 1337
 		}
-		if !ctx.GlobalIsSet(RecoveryNetworkRPCFlag.Name) {
-			cfg.RecoveryNetworkRPC = defaultRecoveryNetworkRPC
-		}
 		// Create new developer account or reuse existing one
 		var (
 			developer accounts.Account
@@ -1319,7 +1375,10 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 		}
 		log.Info("Using developer account", "address", developer.Address)
 
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		// The genesis staking the node key is assembled by the service
+		cfg.DevMode = true
+		cfg.DevFaucet = developer.Address
+		cfg.DevPeriod = uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name))
 	}
 	// TODO(fjl): move trie cache generations into config
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
@@ -1338,6 +1397,9 @@ func setIndexerConfig(ctx *cli.Context, cfg *dex.Config) {
 
 	cfg.Indexer.Plugin = ctx.GlobalString(IndexerPluginFlag.Name)
 	cfg.Indexer.PluginFlags = ctx.GlobalString(IndexerPluginFlagsFlag.Name)
+	if ctx.GlobalIsSet(IndexerBuiltinFlag.Name) {
+		cfg.Indexer.Builtin = splitAndTrimNonEmpty(ctx.GlobalString(IndexerBuiltinFlag.Name))
+	}
 	// copy required dex configs
 	cfg.Indexer.Genesis = cfg.Genesis
 	cfg.Indexer.NetworkID = cfg.NetworkId
@@ -62,3 +62,21 @@ func Test_SplitTagsFlag(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitAndTrimNonEmpty(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"", nil},
+		{" , ,", nil},
+		{"txbyaddr", []string{"txbyaddr"}},
+		{"txbyaddr, govevents", []string{"txbyaddr", "govevents"}},
+		{" txbyaddr,,govevents ,", []string{"txbyaddr", "govevents"}},
+	}
+	for _, tt := range tests {
+		if got := splitAndTrimNonEmpty(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitAndTrimNonEmpty(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
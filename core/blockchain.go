@@ -2203,7 +2203,7 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) (i
 func (bc *BlockChain) InsertDexonHeaderChain(chain []*types.HeaderWithGovState,
 	gov dexcon.GovernanceStateFetcher, verifierCache *dexCore.TSigVerifierCache) (int, error) {
 	start := time.Now()
-	if i, err := bc.hc.ValidateDexonHeaderChain(chain, gov, verifierCache, bc.Validator()); err != nil {
+	if i, err := bc.hc.ValidateDexonHeaderChain(chain, gov, verifierCache, bc.Validator(), false); err != nil {
 		return i, err
 	}
 
@@ -2348,6 +2348,28 @@ func (bc *BlockChain) GetRoundHeight(round uint64) (uint64, bool) {
 	return h.(uint64), true
 }
 
+// NextBlockRound returns the round of the block on top of the current one,
+// moving to the next round once the current one reached its length as the
+// consensus core would.
+func (bc *BlockChain) NextBlockRound() uint64 {
+	current := bc.CurrentBlock()
+	round := current.Round()
+
+	begin, ok := bc.GetRoundHeight(round)
+	if !ok {
+		return round
+	}
+	end := begin + bc.gov.Configuration(round).RoundLength
+	// Round 0 starts at height 0 instead of height 1.
+	if round == 0 {
+		end++
+	}
+	if current.NumberU64()+1 >= end {
+		round++
+	}
+	return round
+}
+
 func (bc *BlockChain) storeRoundHeight(round uint64, height uint64) {
 	bc.roundHeightMap.Store(round, height)
 }
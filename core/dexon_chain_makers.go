@@ -17,27 +17,55 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"math/big"
 	"math/rand"
 	"time"
 
+	coreDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
+	"github.com/dexon-foundation/dexon/core/rawdb"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
 	"github.com/dexon-foundation/dexon/rlp"
 )
 
+// dexonGovTxGas is the gas limit of the governance transactions added by
+// DexonBlockGen.AddGovTx.
+const dexonGovTxGas = 2000000
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 }
 
+// NewDexonChain commits genesis to db and creates a blockchain on top of it,
+// sealed by a dexcon engine fetching the governance state of the chain.
+func NewDexonChain(db ethdb.Database, genesis *Genesis) (*BlockChain, *dexcon.Dexcon, *Governance, error) {
+	if _, _, err := SetupGenesisBlock(db, genesis); err != nil {
+		return nil, nil, nil, err
+	}
+	engine := dexcon.New()
+	chain, err := NewBlockChain(db, nil, genesis.Config, engine, vm.Config{}, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gov := NewGovernance(NewGovernanceStateDB(chain))
+	engine.SetGovStateFetcher(gov)
+	return chain, engine, gov, nil
+}
+
 // DexonBlockGen creates blocks for testing.
 // See GenerateChain for a detailed explanation.
 type DexonBlockGen struct {
@@ -82,10 +110,121 @@ func (b *DexonBlockGen) SetNonce(nonce types.BlockNonce) {
 	b.header.Nonce = nonce
 }
 
+// SetPosition sets the consensus position of the generated block. It defaults
+// to the round of the parent block, at the height of the block.
 func (b *DexonBlockGen) SetPosition(position coreTypes.Position) {
 	b.position = position
 }
 
+// Round returns the round of the generated block.
+func (b *DexonBlockGen) Round() uint64 {
+	return b.position.Round
+}
+
+// NextRound makes the generated block the first block of the round following
+// the round of its parent. The round height is recorded in the governance
+// state by the engine when the block is finalized.
+func (b *DexonBlockGen) NextRound() {
+	b.position.Round = b.parent.Round() + 1
+}
+
+// GovernanceState returns the governance state the transactions of the
+// generated block run on. Blocks with the state modified directly, as by the
+// MarkProposed and Simulate helpers, can't be reproduced by processing them,
+// and have to be written along with their generated state.
+func (b *DexonBlockGen) GovernanceState() *vm.GovernanceState {
+	return &vm.GovernanceState{StateDB: b.statedb}
+}
+
+// MarkProposed records the node owned by owner as a proposer of the generated
+// block, as the engine does for the coinbase. A node of the DKG set of a round
+// which proposed no block in that round is disqualified when the round after
+// begins. Like SimulateDKGSuccess and SimulateDKGReset, it writes the state
+// directly, so the block fails InsertChain; write it with WriteDexonChain.
+func (b *DexonBlockGen) MarkProposed(owner common.Address) {
+	b.GovernanceState().PutLastProposedHeight(owner, b.header.Number)
+}
+
+// SimulateDKGSuccess simulates a successful DKG of the next round among the
+// nodes of the given public keys in the generated block, as if each of them
+// proposed a master public key, got ready, finalized and succeeded. The nodes
+// are then the DKG set of the next round. Unlike the real DKG, no governance
+// transaction is sent and no share can be used to sign.
+func (b *DexonBlockGen) SimulateDKGSuccess(nodes ...*ecdsa.PublicKey) {
+	gs := b.GovernanceState()
+	round := new(big.Int).SetUint64(b.position.Round + 1)
+
+	// Clear the DKG states of the previous round first, as the governance
+	// contract does for the first master public key.
+	if gs.DKGRound().Cmp(round) != 0 {
+		clearDKG(gs)
+		gs.SetDKGRound(round)
+	}
+	threshold := coreUtils.GetDKGThreshold(&coreTypes.Config{
+		NotarySetSize: uint32(gs.NotarySetSize().Uint64())})
+
+	for _, node := range nodes {
+		id := coreTypes.NewNodeID(coreEcdsa.NewPublicKeyFromECDSA(node))
+		if gs.DKGMasterPublicKeyOffset(vm.Bytes32(id.Hash)).Sign() >= 0 {
+			continue
+		}
+		_, pubShares := coreDKG.NewPrivateKeyShares(threshold)
+		mpk, err := rlp.EncodeToBytes(&dkgTypes.MasterPublicKey{
+			ProposerID:      id,
+			Round:           round.Uint64(),
+			Reset:           gs.DKGResetCount(round).Uint64(),
+			DKGID:           coreDKG.NewID(id.Bytes()),
+			PublicKeyShares: *pubShares.Move(),
+		})
+		if err != nil {
+			panic(err)
+		}
+		gs.PutDKGMasterPublicKeyOffset(vm.Bytes32(id.Hash), gs.LenDKGMasterPublicKeys())
+		gs.PushDKGMasterPublicKey(mpk)
+
+		addr := vm.IdToAddress(id)
+		gs.PutDKGMPKReady(addr, true)
+		gs.IncDKGMPKReadysCount()
+		gs.PutDKGFinalized(addr, true)
+		gs.IncDKGFinalizedsCount()
+		gs.PutDKGSuccess(addr, true)
+		gs.IncDKGSuccessesCount()
+	}
+}
+
+// SimulateDKGReset simulates a reset of the failed DKG of the next round in
+// the generated block. The DKG states are cleared and the reset counted, and
+// the CRS of the next round is replaced as if it were signed again.
+func (b *DexonBlockGen) SimulateDKGReset() {
+	gs := b.GovernanceState()
+	round := new(big.Int).SetUint64(b.position.Round + 1)
+
+	clearDKG(gs)
+	gs.SetDKGRound(round)
+
+	gs.SetCRS(crypto.Keccak256Hash(gs.CRS().Bytes()))
+	gs.SetCRSRound(round)
+	gs.IncDKGResetCount(round)
+}
+
+// clearDKG clears the DKG states of the participants of the last DKG.
+func clearDKG(gs *vm.GovernanceState) {
+	dkgSet := make(map[coreTypes.NodeID]struct{})
+	for _, mpk := range gs.DKGMasterPublicKeyItems() {
+		dkgSet[mpk.ProposerID] = struct{}{}
+	}
+	gs.ClearDKGMasterPublicKeyOffset()
+	gs.ClearDKGMasterPublicKeys()
+	gs.ClearDKGComplaintProposed()
+	gs.ClearDKGComplaints()
+	gs.ClearDKGMPKReadys(dkgSet)
+	gs.ResetDKGMPKReadysCount()
+	gs.ClearDKGFinalizeds(dkgSet)
+	gs.ResetDKGFinalizedsCount()
+	gs.ClearDKGSuccesses(dkgSet)
+	gs.ResetDKGSuccessesCount()
+}
+
 // AddTx adds a transaction to the generated block. If no coinbase has
 // been set, the block's coinbase is set to the zero address.
 //
@@ -94,11 +233,30 @@ func (b *DexonBlockGen) SetPosition(position coreTypes.Position) {
 // further limitations on the content of transactions that can be
 // added. If contract code relies on the BLOCKHASH instruction,
 // the block in chain will be returned.
+//
+// The nonce of the sender is tracked for TxNonce, but not checked. Unsigned
+// transactions are added as is.
 func (b *DexonBlockGen) AddTx(tx *types.Transaction) {
-	// TODO: check nonce and maintain nonce.
+	if from, err := types.Sender(types.MakeSigner(b.config, b.header.Number), tx); err == nil {
+		b.dirtyNonce[from] = tx.Nonce() + 1
+	}
 	b.txs = append(b.txs, tx)
 }
 
+// AddGovTx adds a transaction calling the governance contract with input and
+// value attached, sent from the account of key at its next nonce.
+func (b *DexonBlockGen) AddGovTx(key *ecdsa.PrivateKey, value *big.Int, input []byte) {
+	nonce := b.TxNonce(crypto.PubkeyToAddress(key.PublicKey))
+	gasPrice := b.GovernanceState().MinGasPrice()
+
+	tx := types.NewTransaction(nonce, vm.GovernanceContractAddress, value, dexonGovTxGas, gasPrice, input)
+	tx, err := types.SignTx(tx, types.MakeSigner(b.config, b.header.Number), key)
+	if err != nil {
+		panic(err)
+	}
+	b.AddTx(tx)
+}
+
 func (b *DexonBlockGen) PreparePayload() []byte {
 	return nil
 }
@@ -118,6 +276,15 @@ func (b *DexonBlockGen) ProcessTransactions(c ChainContext) {
 	}
 }
 
+// OffsetTime modifies the time instance of a block. Unlike the Ethereum
+// blocks, the DEXON block times are in milliseconds.
+func (b *DexonBlockGen) OffsetTime(milliseconds int64) {
+	b.header.Time += uint64(milliseconds)
+	if b.header.Time <= b.parent.Header().Time {
+		panic("block time out of range")
+	}
+}
+
 // Number returns the block number of the block being generated.
 func (b *DexonBlockGen) Number() *big.Int {
 	return new(big.Int).Set(b.header.Number)
@@ -157,6 +324,30 @@ func (b *DexonBlockGen) PrevBlock(index int) *types.Block {
 	return b.chain[index]
 }
 
+// WriteDexonChain generates n blocks on top of the current block of chain, and
+// writes them one by one with their generated state, so that the engine
+// finalizes each block with the state of its parent. Blocks built with
+// MarkProposed, SimulateDKGSuccess or SimulateDKGReset must be written this
+// way, as their states can't be reproduced by processing the blocks. Since
+// each block is generated alone, PrevBlock only returns the parent.
+func WriteDexonChain(chain *BlockChain, n int, gen func(int, *DexonBlockGen)) (types.Blocks, error) {
+	blocks := make(types.Blocks, 0, n)
+	for i := 0; i < n; i++ {
+		generated, receipts := GenerateDexonChain(chain.Config(), chain.CurrentBlock(), chain.Engine(), chain.db, 1, func(_ int, b *DexonBlockGen) {
+			gen(i, b)
+		})
+		statedb, err := chain.StateAt(generated[0].Root())
+		if err != nil {
+			return blocks, fmt.Errorf("state of block %d: %v", i, err)
+		}
+		if _, err := chain.WriteBlockWithState(generated[0], receipts[0], statedb); err != nil {
+			return blocks, fmt.Errorf("write block %d: %v", i, err)
+		}
+		blocks = append(blocks, generated[0])
+	}
+	return blocks, nil
+}
+
 func GenerateDexonChain(config *params.ChainConfig, parent *types.Block, engine consensus.Engine, db ethdb.Database, n int, gen func(int, *DexonBlockGen)) ([]*types.Block, []types.Receipts) {
 	if config == nil {
 		config = params.TestChainConfig
@@ -176,6 +367,11 @@ func GenerateDexonChain(config *params.ChainConfig, parent *types.Block, engine
 	genblock := func(i int, parent *types.Block, statedb *state.StateDB) (*types.Block, types.Receipts) {
 		b := &DexonBlockGen{i: i, chain: blocks, parent: parent, statedb: statedb, config: config, engine: engine}
 		b.header = makeDexonHeader(chain, parent, statedb, b.engine)
+		b.dirtyNonce = make(map[common.Address]uint64)
+		b.position = coreTypes.Position{
+			Round:  parent.Round(),
+			Height: b.header.Number.Uint64(),
+		}
 
 		// Execute any user modifications to the block
 		if gen != nil {
@@ -244,9 +440,10 @@ func makeDexconMeta(b *DexonBlockGen, parent *types.Block) []byte {
 	if h < 0 {
 		h = 0
 	}
-	witnessedBlock := b.chain[h]
-	if witnessedBlock == nil {
-		witnessedBlock = parent
+	// Only the generated blocks are at hand, witness the parent otherwise.
+	witnessedBlock := parent
+	if i := h - (parent.Number().Int64() - int64(b.i)) - 1; i >= 0 && i < int64(b.i) {
+		witnessedBlock = b.chain[i]
 	}
 	witnessedBlockHash := witnessedBlock.Hash()
 	data, err := rlp.EncodeToBytes(&witnessedBlockHash)
@@ -294,10 +491,14 @@ func (f *fakeDexonChain) GetHeader(hash common.Hash, number uint64) *types.Heade
 func (f *fakeDexonChain) GetBlock(hash common.Hash, number uint64) *types.Block   { return nil }
 
 func (f *fakeDexonChain) GetHeaderByNumber(number uint64) *types.Header {
-	if number == 0 {
+	if header, ok := f.headersByNumber[number]; ok {
+		return header
+	}
+	if number == f.genesis.NumberU64() {
 		return f.genesis.Header()
 	}
-	return f.headersByNumber[number]
+	// Blocks may be generated on top of a chain stored in the database.
+	return rawdb.ReadHeader(f.db, rawdb.ReadCanonicalHash(f.db, number), number)
 }
 
 func (f *fakeDexonChain) StateAt(hash common.Hash) (*state.StateDB, error) {
@@ -308,6 +509,15 @@ func (f *fakeDexonChain) GetRoundHeight(round uint64) (uint64, bool) {
 	if round == 0 {
 		return 0, true
 	}
-	height, ok := f.roundHeight[round]
-	return height, ok
+	if height, ok := f.roundHeight[round]; ok {
+		return height, true
+	}
+	// The rounds before the generated blocks are in the governance state.
+	statedb, err := f.StateAt(f.genesis.Root())
+	if err != nil {
+		return 0, false
+	}
+	gs := vm.GovernanceState{StateDB: statedb}
+	height := gs.RoundHeight(new(big.Int).SetUint64(round)).Uint64()
+	return height, height != 0
 }
@@ -0,0 +1,187 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+)
+
+// dexonTestChain is a chain of n validators staked in the genesis, which the
+// dexcon engine finalizes with the governance state of the chain.
+type dexonTestChain struct {
+	chain *BlockChain
+	gov   *Governance
+	keys  []*ecdsa.PrivateKey
+}
+
+func newDexonTestChain(t *testing.T, n int, alloc GenesisAlloc) *dexonTestChain {
+	keys := make([]*ecdsa.PrivateKey, n)
+	pubkeys := make([]*ecdsa.PublicKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pubkeys[i] = &keys[i].PublicKey
+	}
+	genesis := DeveloperDexconGenesisBlock(0, pubkeys, common.Address{1})
+	for addr, account := range alloc {
+		genesis.Alloc[addr] = account
+	}
+	chain, _, gov, err := NewDexonChain(ethdb.NewMemDatabase(), genesis)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return &dexonTestChain{chain: chain, gov: gov, keys: keys}
+}
+
+// generate generates n blocks on top of the chain with WriteDexonChain.
+func (c *dexonTestChain) generate(t *testing.T, n int, gen func(int, *DexonBlockGen)) {
+	if _, err := WriteDexonChain(c.chain, n, gen); err != nil {
+		t.Fatalf("failed to write blocks: %v", err)
+	}
+}
+
+func (c *dexonTestChain) pubkeys() []*ecdsa.PublicKey {
+	pubkeys := make([]*ecdsa.PublicKey, len(c.keys))
+	for i, key := range c.keys {
+		pubkeys[i] = &key.PublicKey
+	}
+	return pubkeys
+}
+
+func (c *dexonTestChain) markAllProposed(b *DexonBlockGen) {
+	for _, key := range c.keys {
+		b.MarkProposed(crypto.PubkeyToAddress(key.PublicKey))
+	}
+}
+
+// Tests that the nodes of the DKG set which proposed no block in a round are
+// disqualified when the round after begins, and only them.
+func TestDexonBlockGenDisqualify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	funds := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e7))
+
+	c := newDexonTestChain(t, 4, GenesisAlloc{addr: {Balance: funds, Staked: new(big.Int)}})
+	minStake := c.gov.GetHeadState().MinStake()
+
+	c.generate(t, 3, func(i int, b *DexonBlockGen) {
+		switch i {
+		case 0:
+			b.SimulateDKGSuccess(c.pubkeys()...)
+		case 1:
+			b.NextRound()
+			// The last node of the DKG set doesn't propose in round 1.
+			for _, key := range c.keys[:3] {
+				b.MarkProposed(crypto.PubkeyToAddress(key.PublicKey))
+			}
+			// A node out of the DKG set is never disqualified.
+			input, err := vm.PackRegister(crypto.FromECDSAPub(&key.PublicKey), "Test Node", "", "", "")
+			if err != nil {
+				t.Fatalf("failed to pack register: %v", err)
+			}
+			b.AddGovTx(key, minStake, input)
+		case 2:
+			b.NextRound()
+		}
+	})
+	if round := c.chain.CurrentBlock().Round(); round != 2 {
+		t.Fatalf("round mismatch: have %d, want 2", round)
+	}
+	qualified := make(map[common.Address]bool)
+	for _, node := range c.gov.GetHeadState().QualifiedNodes() {
+		qualified[node.Owner] = true
+	}
+	for i, key := range c.keys {
+		if owner := crypto.PubkeyToAddress(key.PublicKey); qualified[owner] != (i < 3) {
+			t.Errorf("node %d qualification mismatch: have %v, want %v", i, qualified[owner], i < 3)
+		}
+	}
+	if !qualified[addr] {
+		t.Errorf("registered node disqualified")
+	}
+}
+
+// Tests that the mining is halved once the total supply reaches the halving
+// checkpoint, with the block reward halved from the configuration round after.
+func TestDexonBlockGenHalving(t *testing.T) {
+	c := newDexonTestChain(t, 4, nil)
+	lastHalved := c.gov.GetHeadState().LastHalvedAmount()
+
+	c.generate(t, 4, func(i int, b *DexonBlockGen) {
+		b.SetCoinbase(crypto.PubkeyToAddress(c.keys[0].PublicKey))
+		if i > 0 {
+			b.NextRound()
+			c.markAllProposed(b)
+		}
+		switch i {
+		case 0:
+			// Bring the total supply right before the halving checkpoint.
+			gs := b.GovernanceState()
+			gap := new(big.Int).Sub(gs.NextHalvingSupply(), gs.TotalSupply())
+			gs.IncTotalSupply(gap.Sub(gap, common.Big1))
+			fallthrough
+		case 1:
+			b.SimulateDKGSuccess(c.pubkeys()...)
+		}
+	})
+	gs := c.gov.GetHeadState()
+	if have, want := gs.LastHalvedAmount(), new(big.Int).Div(lastHalved, common.Big2); have.Cmp(want) != 0 {
+		t.Errorf("last halved amount mismatch: have %v, want %v", have, want)
+	}
+	// The configuration of round 3 is the one at the beginning of round 1.
+	before := c.chain.GetBlockByNumber(1).Reward()
+	after := c.chain.GetBlockByNumber(4).Reward()
+	if diff := new(big.Int).Sub(before, new(big.Int).Mul(after, common.Big2)); diff.CmpAbs(common.Big2) > 0 {
+		t.Errorf("block reward not halved: have %v, before %v", after, before)
+	}
+}
+
+// Tests that the DKG states cached by the governance follow the DKG successes
+// and resets.
+func TestGovernanceDKGCache(t *testing.T) {
+	c := newDexonTestChain(t, 4, nil)
+
+	c.generate(t, 1, func(i int, b *DexonBlockGen) {
+		b.NextRound()
+		b.SimulateDKGSuccess(c.pubkeys()...)
+	})
+	if mpks := c.gov.DKGMasterPublicKeys(2); len(mpks) != 4 {
+		t.Fatalf("master public key count mismatch: have %d, want 4", len(mpks))
+	}
+	if !c.gov.IsDKGMPKReady(2) || !c.gov.IsDKGFinal(2) || !c.gov.IsDKGSuccess(2) {
+		t.Errorf("DKG not successful")
+	}
+
+	c.generate(t, 1, func(i int, b *DexonBlockGen) {
+		b.SimulateDKGReset()
+	})
+	if count := c.gov.DKGResetCount(2); count != 1 {
+		t.Errorf("reset count mismatch: have %d, want 1", count)
+	}
+	if mpks := c.gov.DKGMasterPublicKeys(2); len(mpks) != 0 {
+		t.Errorf("stale master public keys: have %d, want 0", len(mpks))
+	}
+	if c.gov.IsDKGSuccess(2) {
+		t.Errorf("DKG successful after reset")
+	}
+
+	c.generate(t, 1, func(i int, b *DexonBlockGen) {
+		b.SimulateDKGSuccess(c.pubkeys()[:3]...)
+	})
+	addrs, err := c.gov.DKGSetNodeKeyAddresses(2)
+	if err != nil {
+		t.Fatalf("failed to get DKG set: %v", err)
+	}
+	if len(addrs) != 3 {
+		t.Errorf("DKG set size mismatch: have %d, want 3", len(addrs))
+	}
+	for _, mpk := range c.gov.DKGMasterPublicKeys(2) {
+		if mpk.Reset != 1 {
+			t.Errorf("master public key reset mismatch: have %d, want 1", mpk.Reset)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -33,6 +34,7 @@ import (
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/params"
@@ -459,6 +461,54 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DeveloperDexconGenesisBlock returns a DEXON genesis block for local
+// development networks, starting the consensus at dMoment (in seconds) with
+// the given validators staked. The round length and consensus lambdas are
+// shortened so that rounds and DKGs complete in minutes, and the faucet is
+// pre-funded and owns the governance contract.
+func DeveloperDexconGenesisBlock(dMoment uint64, validators []*ecdsa.PublicKey, faucet common.Address) *Genesis {
+	config := *params.TestnetChainConfig
+	dexcon := *config.Dexcon
+	recovery := *config.Recovery
+	config.ChainID = big.NewInt(1337)
+	config.DMoment = dMoment
+	config.Dexcon, config.Recovery = &dexcon, &recovery
+
+	dexcon.Owner = faucet
+	dexcon.BlockGasLimit = 40000000
+	dexcon.LambdaBA = 250
+	dexcon.LambdaDKG = 1000
+	dexcon.RoundLength = 100
+	dexcon.MinBlockInterval = 500
+	recovery.Timeout = 30
+	recovery.Confirmation = 1
+
+	// Validators stake the minimum stake, keeping as much for transactions
+	alloc := GenesisAlloc{
+		faucet: {
+			Balance: new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18)),
+			Staked:  big.NewInt(0),
+		},
+	}
+	for i, key := range validators {
+		alloc[crypto.PubkeyToAddress(*key)] = GenesisAccount{
+			Balance:   new(big.Int).Mul(dexcon.MinStake, big.NewInt(2)),
+			Staked:    new(big.Int).Set(dexcon.MinStake),
+			PublicKey: crypto.FromECDSAPub(key),
+			NodeInfo: NodeInfo{
+				Name: fmt.Sprintf("DEXON Devnet Node %d", i),
+			},
+		}
+	}
+	return &Genesis{
+		Config:     &config,
+		Timestamp:  dMoment * 1000,
+		GasLimit:   dexcon.BlockGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	type accountData struct {
 		Balance   *big.Int
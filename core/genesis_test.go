@@ -17,6 +17,7 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"reflect"
 	"testing"
@@ -25,7 +26,9 @@ import (
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus/ethash"
 	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
 )
@@ -182,3 +185,38 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the developer DEXON genesis stakes its validators and leaves the
+// testnet configuration untouched.
+func TestDeveloperDexconGenesisBlock(t *testing.T) {
+	var validators []*ecdsa.PublicKey
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		validators = append(validators, &key.PublicKey)
+	}
+	faucet := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	genesis := DeveloperDexconGenesisBlock(1500000000, validators, faucet)
+
+	if params.TestnetChainConfig.Dexcon.Owner == faucet || params.TestnetChainConfig.DMoment == 1500000000 {
+		t.Fatalf("testnet chain config modified")
+	}
+	db := ethdb.NewMemDatabase()
+	block := genesis.ToBlock(db)
+	if block.Time() != 1500000000*1000 {
+		t.Errorf("genesis timestamp mismatch: have %d, want %d", block.Time(), 1500000000*1000)
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	gov := &vm.GovernanceState{StateDB: statedb}
+	if qualified := len(gov.QualifiedNodes()); qualified != len(validators) {
+		t.Errorf("qualified node count mismatch: have %d, want %d", qualified, len(validators))
+	}
+	if size := gov.NotarySetSize().Uint64(); size != uint64(len(validators)) {
+		t.Errorf("notary set size mismatch: have %d, want %d", size, len(validators))
+	}
+	if owner := gov.Owner(); owner != faucet {
+		t.Errorf("governance owner mismatch: have %x, want %x", owner, faucet)
+	}
+}
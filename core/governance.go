@@ -1,6 +1,7 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"encoding/hex"
 	"fmt"
 	"math/big"
@@ -17,6 +18,7 @@ import (
 	"github.com/hashicorp/golang-lru/simplelru"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
 	"github.com/dexon-foundation/dexon/crypto"
@@ -326,3 +328,28 @@ func (g *Governance) MinGasPrice(round uint64) *big.Int {
 func (g *Governance) DKGResetCount(round uint64) uint64 {
 	return g.GetHeadState().DKGResetCount(big.NewInt(int64(round))).Uint64()
 }
+
+// SoleNotaryGovStateFetcher is the governance state fetcher of the consensus
+// engine of a chain with a sole notary, as sealed in developer mode or by the
+// simulated backend. No DKG ever runs with a sole notary, so it reports the
+// notary as the DKG set of every round instead.
+type SoleNotaryGovStateFetcher struct {
+	dexcon.GovernanceStateFetcher
+	nodeKeyAddress common.Address
+}
+
+// NewSoleNotaryGovStateFetcher returns the governance state fetcher of a chain
+// with the node of key notary as the sole notary.
+func NewSoleNotaryGovStateFetcher(gov dexcon.GovernanceStateFetcher,
+	notary *ecdsa.PublicKey) *SoleNotaryGovStateFetcher {
+	return &SoleNotaryGovStateFetcher{
+		GovernanceStateFetcher: gov,
+		nodeKeyAddress: vm.IdToAddress(
+			coreTypes.NewNodeID(coreEcdsa.NewPublicKeyFromECDSA(notary))),
+	}
+}
+
+// DKGSetNodeKeyAddresses implements dexcon.GovernanceStateFetcher.
+func (f *SoleNotaryGovStateFetcher) DKGSetNodeKeyAddresses(round uint64) (map[common.Address]struct{}, error) {
+	return map[common.Address]struct{}{f.nodeKeyAddress: {}}, nil
+}
@@ -463,9 +463,13 @@ func (c *headerVerifierCache) configuration(round uint64) *params.DexconConfig {
 	return cfg
 }
 
+// ValidateDexonHeaderChain verifies the given DEXON header chain. With
+// strictTSig set, headers whose randomness fails the threshold signature
+// verification are rejected; light clients set it, since they have no other
+// proof of the randomness.
 func (hc *HeaderChain) ValidateDexonHeaderChain(chain []*types.HeaderWithGovState,
 	gov dexcon.GovernanceStateFetcher,
-	verifierCache *dexCore.TSigVerifierCache, validator Validator) (int, error) {
+	verifierCache *dexCore.TSigVerifierCache, validator WitnessValidator, strictTSig bool) (int, error) {
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 1; i < len(chain); i++ {
 		if chain[i].Number.Uint64() != chain[i-1].Number.Uint64()+1 || chain[i].ParentHash != chain[i-1].Hash() {
@@ -481,7 +485,7 @@ func (hc *HeaderChain) ValidateDexonHeaderChain(chain []*types.HeaderWithGovStat
 	// If the last TSig pass the verification, we don't need to verify others.
 	cache := newHeaderVerifierCache(verifierCache, gov)
 	verifyTSig := false
-	if err := hc.verifyDexonHeader(chain[len(chain)-1].Header, gov, cache, true); err != nil {
+	if err := hc.verifyDexonHeader(chain[len(chain)-1].Header, gov, cache, true, strictTSig); err != nil {
 		verifyTSig = true
 	}
 	// Iterate over the headers and ensure they all check out
@@ -499,7 +503,7 @@ func (hc *HeaderChain) ValidateDexonHeaderChain(chain []*types.HeaderWithGovStat
 			}
 		}
 
-		if err := hc.verifyDexonHeader(header.Header, gov, cache, verifyTSig); err != nil {
+		if err := hc.verifyDexonHeader(header.Header, gov, cache, verifyTSig, strictTSig); err != nil {
 			return i, err
 		}
 
@@ -534,13 +538,13 @@ func (hc *HeaderChain) ValidateDexonHeaderChain(chain []*types.HeaderWithGovStat
 
 func (hc *HeaderChain) VerifyDexonHeader(header *types.Header,
 	gov dexcon.GovernanceStateFetcher,
-	verifierCache *dexCore.TSigVerifierCache, validator Validator) error {
+	verifierCache *dexCore.TSigVerifierCache, validator WitnessValidator) error {
 
 	if parent := hc.GetHeader(header.ParentHash, header.Number.Uint64()-1); parent == nil {
 		return consensus.ErrUnknownAncestor
 	}
 	cache := newHeaderVerifierCache(verifierCache, gov)
-	if err := hc.verifyDexonHeader(header, gov, cache, true); err != nil {
+	if err := hc.verifyDexonHeader(header, gov, cache, true, false); err != nil {
 		return err
 	}
 
@@ -567,7 +571,7 @@ func (hc *HeaderChain) VerifyDexonHeader(header *types.Header,
 
 func (hc *HeaderChain) verifyDexonHeader(header *types.Header,
 	gov dexcon.GovernanceStateFetcher,
-	cache *headerVerifierCache, verifyTSig bool) error {
+	cache *headerVerifierCache, verifyTSig, strictTSig bool) error {
 
 	// If the header is a banned one, straight out abort
 	if BadHashes[header.Hash()] {
@@ -588,8 +592,16 @@ func (hc *HeaderChain) verifyDexonHeader(header *types.Header,
 
 	if verifyTSig {
 		if err := hc.verifyTSig(&coreBlock, cache.verifierCache); err != nil {
-			log.Debug("verify header sig fail, number=%d, err=%v",
-				header.Number.Uint64(), err)
+			switch {
+			case strictTSig:
+				return fmt.Errorf("verify header sig fail, number=%d, err=%v",
+					header.Number.Uint64(), err)
+			case err == errInvalidTSig:
+				log.Debug("verify header sig fail, number=%d, err=%v",
+					header.Number.Uint64(), err)
+			default:
+				panic(err)
+			}
 		}
 	}
 
@@ -631,6 +643,10 @@ func (hc *HeaderChain) verifyDexonHeader(header *types.Header,
 	return nil
 }
 
+// errInvalidTSig is returned if the randomness of a block fails the threshold
+// signature verification.
+var errInvalidTSig = errors.New("signature invalid")
+
 func (hc *HeaderChain) verifyTSig(coreBlock *coreTypes.Block,
 	verifierCache *dexCore.TSigVerifierCache) error {
 
@@ -644,17 +660,17 @@ func (hc *HeaderChain) verifyTSig(coreBlock *coreTypes.Block,
 	// Verify threshold signature
 	v, ok, err := verifierCache.UpdateAndGet(round)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	if !ok {
-		panic(fmt.Errorf("DKG of round %d is not finished", round))
+		return fmt.Errorf("DKG of round %d is not finished", round)
 	}
 
 	if !v.VerifySignature(coreBlock.Hash, coreCrypto.Signature{
 		Type:      "bls",
 		Signature: randomness}) {
-		return fmt.Errorf("signature invalid")
+		return errInvalidTSig
 	}
 	return nil
 }
@@ -0,0 +1,179 @@
+package rawdb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// ReadCoreCacheVoteCount retrieves the number of votes cached at a position.
+func ReadCoreCacheVoteCount(db DatabaseReader, pos coreTypes.Position) uint64 {
+	data, _ := db.Get(coreCachePositionKey(coreCacheVoteCountPrefix, pos.Round, pos.Height))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// ReadCoreCacheVotes retrieves the votes cached at a position.
+func ReadCoreCacheVotes(db DatabaseReader, pos coreTypes.Position) []*coreTypes.Vote {
+	count := ReadCoreCacheVoteCount(db, pos)
+	votes := make([]*coreTypes.Vote, 0, count)
+	for i := uint64(0); i < count; i++ {
+		data, _ := db.Get(coreCacheVoteKey(pos.Round, pos.Height, i))
+		if len(data) == 0 {
+			continue
+		}
+		vote := new(coreTypes.Vote)
+		if err := rlp.Decode(bytes.NewReader(data), vote); err != nil {
+			log.Error("Invalid core cache vote RLP", "position", pos, "index", i, "err", err)
+			continue
+		}
+		votes = append(votes, vote)
+	}
+	return votes
+}
+
+// WriteCoreCacheVote stores the vote cached at a position as the index-th
+// one, and the number of votes cached at the position to index + 1.
+func WriteCoreCacheVote(db DatabaseWriter, index uint64, vote *coreTypes.Vote) {
+	data, err := rlp.EncodeToBytes(vote)
+	if err != nil {
+		log.Crit("Failed to RLP encode core cache vote", "err", err)
+	}
+	pos := vote.Position
+	if err := db.Put(coreCacheVoteKey(pos.Round, pos.Height, index), data); err != nil {
+		log.Crit("Failed to store core cache vote", "err", err)
+	}
+	var count [8]byte
+	binary.BigEndian.PutUint64(count[:], index+1)
+	if err := db.Put(coreCachePositionKey(coreCacheVoteCountPrefix, pos.Round, pos.Height), count[:]); err != nil {
+		log.Crit("Failed to store core cache vote count", "err", err)
+	}
+}
+
+// DeleteCoreCacheVotes removes the count votes cached at a position.
+func DeleteCoreCacheVotes(db DatabaseDeleter, pos coreTypes.Position, count uint64) {
+	for i := uint64(0); i < count; i++ {
+		if err := db.Delete(coreCacheVoteKey(pos.Round, pos.Height, i)); err != nil {
+			log.Crit("Failed to delete core cache vote", "err", err)
+		}
+	}
+	if err := db.Delete(coreCachePositionKey(coreCacheVoteCountPrefix, pos.Round, pos.Height)); err != nil {
+		log.Crit("Failed to delete core cache vote count", "err", err)
+	}
+}
+
+// ReadCoreCacheFinalizedBlock retrieves the finalized block cached at a
+// position.
+func ReadCoreCacheFinalizedBlock(db DatabaseReader, pos coreTypes.Position) *coreTypes.Block {
+	data, _ := db.Get(coreCachePositionKey(coreCacheFinalizedBlockPrefix, pos.Round, pos.Height))
+	if len(data) == 0 {
+		return nil
+	}
+	block := new(coreTypes.Block)
+	if err := rlp.Decode(bytes.NewReader(data), block); err != nil {
+		log.Error("Invalid core cache finalized block RLP", "position", pos, "err", err)
+		return nil
+	}
+	return block
+}
+
+// WriteCoreCacheFinalizedBlock stores a finalized block by its position.
+func WriteCoreCacheFinalizedBlock(db DatabaseWriter, block *coreTypes.Block) {
+	data, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		log.Crit("Failed to RLP encode core cache finalized block", "err", err)
+	}
+	key := coreCachePositionKey(coreCacheFinalizedBlockPrefix, block.Position.Round, block.Position.Height)
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store core cache finalized block", "err", err)
+	}
+}
+
+// DeleteCoreCacheFinalizedBlock removes the finalized block cached at a
+// position.
+func DeleteCoreCacheFinalizedBlock(db DatabaseDeleter, pos coreTypes.Position) {
+	if err := db.Delete(coreCachePositionKey(coreCacheFinalizedBlockPrefix, pos.Round, pos.Height)); err != nil {
+		log.Crit("Failed to delete core cache finalized block", "err", err)
+	}
+}
+
+// ReadCoreCacheHeights retrieves the heights cached in a round.
+func ReadCoreCacheHeights(db DatabaseReader, round uint64) []uint64 {
+	return readUint64s(db, coreCacheRoundKey(coreCacheHeightsPrefix, round))
+}
+
+// WriteCoreCacheHeights stores the heights cached in a round.
+func WriteCoreCacheHeights(db DatabaseWriter, round uint64, heights []uint64) {
+	writeUint64s(db, coreCacheRoundKey(coreCacheHeightsPrefix, round), heights)
+}
+
+// DeleteCoreCacheHeights removes the heights cached in a round.
+func DeleteCoreCacheHeights(db DatabaseDeleter, round uint64) {
+	if err := db.Delete(coreCacheRoundKey(coreCacheHeightsPrefix, round)); err != nil {
+		log.Crit("Failed to delete core cache heights", "err", err)
+	}
+}
+
+// ReadCoreCacheSize retrieves the bytes cached in a round.
+func ReadCoreCacheSize(db DatabaseReader, round uint64) uint64 {
+	data, _ := db.Get(coreCacheRoundKey(coreCacheSizePrefix, round))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteCoreCacheSize stores the bytes cached in a round.
+func WriteCoreCacheSize(db DatabaseWriter, round uint64, size uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], size)
+	if err := db.Put(coreCacheRoundKey(coreCacheSizePrefix, round), data[:]); err != nil {
+		log.Crit("Failed to store core cache size", "err", err)
+	}
+}
+
+// DeleteCoreCacheSize removes the bytes cached in a round.
+func DeleteCoreCacheSize(db DatabaseDeleter, round uint64) {
+	if err := db.Delete(coreCacheRoundKey(coreCacheSizePrefix, round)); err != nil {
+		log.Crit("Failed to delete core cache size", "err", err)
+	}
+}
+
+// ReadCoreCacheRounds retrieves the cached rounds.
+func ReadCoreCacheRounds(db DatabaseReader) []uint64 {
+	return readUint64s(db, coreCacheRoundsKey)
+}
+
+// WriteCoreCacheRounds stores the cached rounds.
+func WriteCoreCacheRounds(db DatabaseWriter, rounds []uint64) {
+	writeUint64s(db, coreCacheRoundsKey, rounds)
+}
+
+func readUint64s(db DatabaseReader, key []byte) []uint64 {
+	data, _ := db.Get(key)
+	if len(data) == 0 {
+		return nil
+	}
+	var values []uint64
+	if err := rlp.Decode(bytes.NewReader(data), &values); err != nil {
+		log.Error("Invalid core cache index RLP", "key", string(key), "err", err)
+		return nil
+	}
+	return values
+}
+
+func writeUint64s(db DatabaseWriter, key []byte, values []uint64) {
+	data, err := rlp.EncodeToBytes(values)
+	if err != nil {
+		log.Crit("Failed to RLP encode core cache index", "err", err)
+	}
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store core cache index", "err", err)
+	}
+}
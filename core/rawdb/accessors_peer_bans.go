@@ -0,0 +1,38 @@
+package rawdb
+
+import (
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// PeerBan is a temporary ban of a misbehaving peer.
+type PeerBan struct {
+	ID     string
+	Expiry uint64 // Unix time the ban ends at
+	Reason string
+}
+
+// ReadPeerBans retrieves the bans of misbehaving peers.
+func ReadPeerBans(db DatabaseReader) []PeerBan {
+	data, _ := db.Get(peerBansKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var bans []PeerBan
+	if err := rlp.DecodeBytes(data, &bans); err != nil {
+		log.Error("Invalid peer bans RLP", "err", err)
+		return nil
+	}
+	return bans
+}
+
+// WritePeerBans stores the bans of misbehaving peers.
+func WritePeerBans(db DatabaseWriter, bans []PeerBan) {
+	data, err := rlp.EncodeToBytes(bans)
+	if err != nil {
+		log.Crit("Failed to RLP encode peer bans", "err", err)
+	}
+	if err := db.Put(peerBansKey, data); err != nil {
+		log.Crit("Failed to store peer bans", "err", err)
+	}
+}
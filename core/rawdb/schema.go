@@ -60,11 +60,22 @@ var (
 	coreCompactionChainTipKey = []byte("CoreChainTip")
 	coreDKGProtocolKey        = []byte("CoreDKGProtocol")
 
+	coreCacheVotePrefix           = []byte("CoreCacheV") // coreCacheVotePrefix + round (uint64 big endian) + height (uint64 big endian) + index (uint64 big endian) -> vote
+	coreCacheVoteCountPrefix      = []byte("CoreCacheC") // coreCacheVoteCountPrefix + round (uint64 big endian) + height (uint64 big endian) -> number of votes
+	coreCacheFinalizedBlockPrefix = []byte("CoreCacheB") // coreCacheFinalizedBlockPrefix + round (uint64 big endian) + height (uint64 big endian) -> block
+	coreCacheHeightsPrefix        = []byte("CoreCacheH") // coreCacheHeightsPrefix + round (uint64 big endian) -> heights cached in round
+	coreCacheSizePrefix           = []byte("CoreCacheS") // coreCacheSizePrefix + round (uint64 big endian) -> bytes cached in round
+	coreCacheRoundsKey            = []byte("CoreCacheRounds")
+
+	peerBansKey = []byte("PeerBans")
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	AddressTxIndexPrefix = []byte("iA") // AddressTxIndexPrefix is the data table of the built-in address transaction indexer
+	GovEventIndexPrefix  = []byte("iG") // GovEventIndexPrefix is the data table of the built-in governance event indexer
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -137,6 +148,33 @@ func coreDKGPrivateKeyKey(round uint64) []byte {
 	return ret
 }
 
+// coreCachePositionKey = prefix + round (uint64 big endian) + height (uint64 big endian)
+func coreCachePositionKey(prefix []byte, round, height uint64) []byte {
+	key := make([]byte, len(prefix)+16)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], round)
+	binary.BigEndian.PutUint64(key[len(prefix)+8:], height)
+	return key
+}
+
+// coreCacheVoteKey = coreCacheVotePrefix + round (uint64 big endian) + height (uint64 big endian) + index (uint64 big endian)
+func coreCacheVoteKey(round, height, index uint64) []byte {
+	key := make([]byte, len(coreCacheVotePrefix)+24)
+	copy(key, coreCacheVotePrefix)
+	binary.BigEndian.PutUint64(key[len(coreCacheVotePrefix):], round)
+	binary.BigEndian.PutUint64(key[len(coreCacheVotePrefix)+8:], height)
+	binary.BigEndian.PutUint64(key[len(coreCacheVotePrefix)+16:], index)
+	return key
+}
+
+// coreCacheRoundKey = prefix + round (uint64 big endian)
+func coreCacheRoundKey(prefix []byte, round uint64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], round)
+	return key
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -35,6 +35,12 @@ type Validator interface {
 	// gas used.
 	ValidateState(block, parent *types.Block, state *state.StateDB, receipts types.Receipts, usedGas uint64) error
 
+	WitnessValidator
+}
+
+// WitnessValidator validates the witness data of DEXON blocks, which only
+// requires the canonical headers of the chain.
+type WitnessValidator interface {
 	// ValidateWitnessData validates the given witness result.
 	ValidateWitnessData(height uint64, data common.Hash) error
 }
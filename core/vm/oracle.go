@@ -18,18 +18,29 @@
 package vm
 
 import (
+	"errors"
+	"math/big"
 	"strings"
 
 	"github.com/dexon-foundation/dexon/accounts/abi"
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/types"
 )
 
 var GovernanceContractAddress = common.HexToAddress("63751838d6485578b23e8b051d40861ecc416794")
 
 var GovernanceABI *OracleContractABI
 
+// rawDataEvents holds the IDs of the events emitted with their only
+// non-indexed argument, of type bytes, as raw data instead of its ABI
+// encoding.
+var rawDataEvents map[common.Hash]struct{}
+
 func init() {
 	GovernanceABI = NewOracleContractABI(GovernanceABIJSON)
+	rawDataEvents = map[common.Hash]struct{}{
+		GovernanceABI.Events["NodePublicKeyReplaced"].Id(): {},
+	}
 }
 
 // OracleContract represent special system contracts written in Go.
@@ -57,6 +68,7 @@ type OracleContractABI struct {
 	Name2Method map[string]abi.Method
 	Sig2Method  map[string]abi.Method
 	Events      map[string]abi.Event
+	Topic2Event map[common.Hash]abi.Event
 }
 
 // NewOracleContractABI parse the ABI.
@@ -75,8 +87,10 @@ func NewOracleContractABI(abiDefinition string) *OracleContractABI {
 	}
 
 	events := make(map[string]abi.Event)
+	topic2Event := make(map[common.Hash]abi.Event)
 	for _, event := range abiObject.Events {
 		events[event.Name] = event
+		topic2Event[event.Id()] = event
 	}
 
 	return &OracleContractABI{
@@ -84,5 +98,56 @@ func NewOracleContractABI(abiDefinition string) *OracleContractABI {
 		Name2Method: name2Method,
 		Sig2Method:  sig2Method,
 		Events:      events,
+		Topic2Event: topic2Event,
+	}
+}
+
+// UnpackLog decodes a log emitted by the oracle contract. It returns the
+// event and the values of its arguments keyed by argument name.
+func (o *OracleContractABI) UnpackLog(l *types.Log) (*abi.Event, map[string]interface{}, error) {
+	if len(l.Topics) == 0 {
+		return nil, nil, errors.New("log without topics")
+	}
+	event, ok := o.Topic2Event[l.Topics[0]]
+	if !ok {
+		return nil, nil, errors.New("unknown event")
+	}
+	args := make(map[string]interface{}, len(event.Inputs))
+
+	// Indexed arguments are stored in the topics following the event ID.
+	topics := l.Topics[1:]
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			continue
+		}
+		if len(topics) == 0 {
+			return nil, nil, errors.New("missing indexed argument")
+		}
+		switch input.Type.T {
+		case abi.AddressTy:
+			args[input.Name] = common.BytesToAddress(topics[0].Bytes())
+		case abi.UintTy, abi.IntTy:
+			args[input.Name] = new(big.Int).SetBytes(topics[0].Bytes())
+		default:
+			args[input.Name] = topics[0]
+		}
+		topics = topics[1:]
+	}
+
+	var (
+		nonIndexed = event.Inputs.NonIndexed()
+		values     []interface{}
+	)
+	if _, raw := rawDataEvents[event.Id()]; raw {
+		values = []interface{}{common.CopyBytes(l.Data)}
+	} else {
+		var err error
+		if values, err = nonIndexed.UnpackValues(l.Data); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, input := range nonIndexed {
+		args[input.Name] = values[i]
 	}
+	return &event, args, nil
 }
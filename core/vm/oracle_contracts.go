@@ -2841,3 +2841,106 @@ func PackResetDKG(newSignedCRS []byte) ([]byte, error) {
 	data := append(method.Id(), res...)
 	return data, nil
 }
+
+func PackRegister(publicKey []byte, name, email, location, url string) ([]byte, error) {
+	method := GovernanceABI.Name2Method["register"]
+	res, err := method.Inputs.Pack(publicKey, name, email, location, url)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackStake() ([]byte, error) {
+	method := GovernanceABI.Name2Method["stake"]
+	return method.Id(), nil
+}
+
+func PackUnstake(amount *big.Int) ([]byte, error) {
+	method := GovernanceABI.Name2Method["unstake"]
+	res, err := method.Inputs.Pack(amount)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackWithdraw() ([]byte, error) {
+	method := GovernanceABI.Name2Method["withdraw"]
+	return method.Id(), nil
+}
+
+func PackPayFine(nodeAddr common.Address) ([]byte, error) {
+	method := GovernanceABI.Name2Method["payFine"]
+	res, err := method.Inputs.Pack(nodeAddr)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackTransferOwnership(newOwner common.Address) ([]byte, error) {
+	method := GovernanceABI.Name2Method["transferOwnership"]
+	res, err := method.Inputs.Pack(newOwner)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackTransferNodeOwnership(newOwner common.Address) ([]byte, error) {
+	method := GovernanceABI.Name2Method["transferNodeOwnership"]
+	res, err := method.Inputs.Pack(newOwner)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackReplaceNodePublicKey(newPublicKey []byte) ([]byte, error) {
+	method := GovernanceABI.Name2Method["replaceNodePublicKey"]
+	res, err := method.Inputs.Pack(newPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+// PackUpdateConfiguration packs an updateConfiguration call. Fields of cfg not
+// covered by the method, such as the mining velocity, are ignored.
+func PackUpdateConfiguration(cfg *params.DexconConfig) ([]byte, error) {
+	method := GovernanceABI.Name2Method["updateConfiguration"]
+	res, err := method.Inputs.Pack(
+		cfg.MinStake,
+		new(big.Int).SetUint64(cfg.LockupPeriod),
+		cfg.MinGasPrice,
+		new(big.Int).SetUint64(cfg.BlockGasLimit),
+		new(big.Int).SetUint64(cfg.LambdaBA),
+		new(big.Int).SetUint64(cfg.LambdaDKG),
+		big.NewInt(int64(cfg.NotaryParamAlpha*decimalMultiplier)),
+		big.NewInt(int64(cfg.NotaryParamBeta*decimalMultiplier)),
+		new(big.Int).SetUint64(cfg.RoundLength),
+		new(big.Int).SetUint64(cfg.MinBlockInterval),
+		cfg.FineValues)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
+
+func PackReport(fineType uint64, arg1, arg2 []byte) ([]byte, error) {
+	method := GovernanceABI.Name2Method["report"]
+	res, err := method.Inputs.Pack(new(big.Int).SetUint64(fineType), arg1, arg2)
+	if err != nil {
+		return nil, err
+	}
+	data := append(method.Id(), res...)
+	return data, nil
+}
@@ -36,6 +36,7 @@ import (
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
@@ -612,6 +613,18 @@ func (g *OracleContractsTestSuite) TestUpdateConfiguration() {
 	// Call with owner.
 	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
 	g.Require().NoError(err)
+
+	// Input packed from a DexconConfig.
+	cfg := g.s.Configuration()
+	cfg.RoundLength = 1200
+	cfg.NotaryParamAlpha = 12.5
+	input, err = PackUpdateConfiguration(cfg)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().Equal(uint64(1200), g.s.RoundLength().Uint64())
+	g.Require().Equal(cfg.NotaryParamAlpha, g.s.Configuration().NotaryParamAlpha)
+	g.Require().Equal(cfg.MinStake, g.s.MinStake())
 }
 
 func (g *OracleContractsTestSuite) TestConfigurationReading() {
@@ -1163,6 +1176,122 @@ func (g *OracleContractsTestSuite) TestResetDKG() {
 	}
 }
 
+func (g *OracleContractsTestSuite) TestPackGovernanceCalls() {
+	privKey, addr := newPrefundAccount(g.stateDB)
+	pk := crypto.FromECDSAPub(&privKey.PublicKey)
+	privKey2, addr2 := newPrefundAccount(g.stateDB)
+	pk2 := crypto.FromECDSAPub(&privKey2.PublicKey)
+	amount := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(5e5))
+	register := func() ([]byte, error) {
+		return PackRegister(pk, "Test1", "test1@dexon.org", "Taipei", "https://dexon.org")
+	}
+
+	// The helpers pack the same input as the ABI.
+	for _, test := range []struct {
+		pack   func() ([]byte, error)
+		method string
+		args   []interface{}
+	}{
+		{register, "register", []interface{}{pk, "Test1", "test1@dexon.org", "Taipei", "https://dexon.org"}},
+		{PackStake, "stake", nil},
+		{func() ([]byte, error) { return PackUnstake(amount) }, "unstake", []interface{}{amount}},
+		{PackWithdraw, "withdraw", nil},
+		{func() ([]byte, error) { return PackPayFine(addr) }, "payFine", []interface{}{addr}},
+		{func() ([]byte, error) { return PackTransferOwnership(addr) }, "transferOwnership", []interface{}{addr}},
+		{func() ([]byte, error) { return PackTransferNodeOwnership(addr) }, "transferNodeOwnership", []interface{}{addr}},
+		{func() ([]byte, error) { return PackReplaceNodePublicKey(pk) }, "replaceNodePublicKey", []interface{}{pk}},
+		{func() ([]byte, error) { return PackReport(FineTypeForkVote, pk, pk2) },
+			"report", []interface{}{big.NewInt(FineTypeForkVote), pk, pk2}},
+	} {
+		have, err := test.pack()
+		g.Require().NoError(err)
+		want, err := GovernanceABI.ABI.Pack(test.method, test.args...)
+		g.Require().NoError(err)
+		g.Require().Equal(want, have, test.method)
+	}
+
+	// The packed inputs drive the contract.
+	input, err := register()
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, amount)
+	g.Require().NoError(err)
+	g.Require().Equal(0, len(g.s.QualifiedNodes()))
+
+	input, err = PackStake()
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, amount)
+	g.Require().NoError(err)
+	g.Require().Equal(1, len(g.s.QualifiedNodes()))
+
+	input, err = PackUnstake(amount)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().Equal(amount.String(), g.s.TotalStaked().String())
+
+	input, err = PackReplaceNodePublicKey(pk2)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().Equal(0, int(g.s.NodesOffsetByNodeKeyAddress(addr2).Int64()))
+
+	input, err = PackTransferNodeOwnership(addr2)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().Equal(0, int(g.s.NodesOffsetByAddress(addr2).Int64()))
+
+	input, err = PackTransferOwnership(addr)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, g.config.Owner, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().Equal(addr, g.s.Owner())
+}
+
+func (g *OracleContractsTestSuite) TestUnpackLog() {
+	privKey, addr := newPrefundAccount(g.stateDB)
+	pk := crypto.FromECDSAPub(&privKey.PublicKey)
+
+	amount := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e6))
+	input, err := GovernanceABI.ABI.Pack("register", pk, "Test1", "test1@dexon.org", "Taipei", "https://dexon.org")
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, amount)
+	g.Require().NoError(err)
+
+	privKey2, _ := newPrefundAccount(g.stateDB)
+	pk2 := crypto.FromECDSAPub(&privKey2.PublicKey)
+	input, err = GovernanceABI.ABI.Pack("replaceNodePublicKey", pk2)
+	g.Require().NoError(err)
+	_, err = g.call(GovernanceContractAddress, addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+
+	var names []string
+	for _, l := range g.stateDB.Logs() {
+		event, args, err := GovernanceABI.UnpackLog(l)
+		g.Require().NoError(err)
+		names = append(names, event.Name)
+		g.Require().Equal(addr, args["NodeAddress"])
+
+		switch event.Name {
+		case "Staked":
+			g.Require().Equal(0, amount.Cmp(args["Amount"].(*big.Int)))
+		case "NodePublicKeyReplaced":
+			g.Require().Equal(pk2, args["PublicKey"])
+		}
+	}
+	g.Require().Equal([]string{"NodeAdded", "Staked", "NodePublicKeyReplaced"}, names)
+
+	_, _, err = GovernanceABI.UnpackLog(&types.Log{Topics: []common.Hash{{}}})
+	g.Require().Error(err)
+
+	// Malformed data is reported rather than returned as raw bytes.
+	_, _, err = GovernanceABI.UnpackLog(&types.Log{
+		Topics: []common.Hash{GovernanceABI.Events["Staked"].Id(), addr.Hash()},
+		Data:   []byte{1, 2, 3},
+	})
+	g.Require().Error(err)
+}
+
 func TestOracleContracts(t *testing.T) {
 	suite.Run(t, new(OracleContractsTestSuite))
 }
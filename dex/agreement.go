@@ -0,0 +1,94 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"errors"
+
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+// verifiedAgreementsLimit is the number of verified agreement results
+// remembered to skip verifying their copies.
+const verifiedAgreementsLimit = 1024
+
+var (
+	errAgreementTSigNotReady = errors.New("group public key of agreement round not ready")
+	errInvalidAgreementTSig  = errors.New("invalid agreement threshold signature")
+)
+
+// tsigVerifierGetter returns the verifier of the threshold signatures of a
+// round, checking them against the group public key of the round.
+type tsigVerifierGetter interface {
+	UpdateAndGet(round uint64) (dexCore.TSigVerifier, bool, error)
+}
+
+// aggregatedAgreement returns the agreement result to relay in place of
+// result. Since DKGDelayRound, the randomness of an agreement result is the
+// threshold signature of the block hash by the notary set, which proves the
+// agreement on its own: the votes are dropped.
+func aggregatedAgreement(result *coreTypes.AgreementResult) *coreTypes.AgreementResult {
+	if result.Position.Round < dexCore.DKGDelayRound ||
+		len(result.Randomness) == 0 || len(result.Votes) == 0 {
+		return result
+	}
+	aggregated := *result
+	aggregated.Votes = nil
+	return &aggregated
+}
+
+// verifyAgreementTSig verifies the randomness of an agreement result against
+// the group public key of its round. Agreement results of the rounds before
+// DKGDelayRound carry no threshold signature and are left to the consensus
+// core to verify.
+func verifyAgreementTSig(verifiers tsigVerifierGetter, result *coreTypes.AgreementResult) error {
+	if result.Position.Round < dexCore.DKGDelayRound {
+		return nil
+	}
+	verifier, ok, err := verifiers.UpdateAndGet(result.Position.Round)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errAgreementTSigNotReady
+	}
+	if !verifier.VerifySignature(result.BlockHash, coreCrypto.Signature{
+		Type:      "bls",
+		Signature: result.Randomness,
+	}) {
+		return errInvalidAgreementTSig
+	}
+	return nil
+}
+
+// verifyAgreement verifies the threshold signature of an agreement result
+// like verifyAgreementTSig. The agreement results verified are remembered by
+// position, so that the copies relayed by other peers are not verified again.
+func (pm *ProtocolManager) verifyAgreement(result *coreTypes.AgreementResult) error {
+	digest := rlpHash([]interface{}{result.BlockHash, result.Randomness})
+	if verified, ok := pm.verifiedAgreements.Get(result.Position); ok && verified == digest {
+		return nil
+	}
+	if err := verifyAgreementTSig(pm.tsigVerifiers, result); err != nil {
+		return err
+	}
+	pm.verifiedAgreements.Add(result.Position, digest)
+	return nil
+}
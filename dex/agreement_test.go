@@ -0,0 +1,156 @@
+package dex
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/dex/downloader"
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+type testTSigVerifier struct {
+	randomness []byte
+	verified   int
+}
+
+func (v *testTSigVerifier) VerifySignature(hash coreCommon.Hash, sig coreCrypto.Signature) bool {
+	v.verified++
+	return reflect.DeepEqual(sig.Signature, v.randomness)
+}
+
+func (v *testTSigVerifier) UpdateAndGet(round uint64) (dexCore.TSigVerifier, bool, error) {
+	return v, true, nil
+}
+
+func newTestAgreement(round uint64) *coreTypes.AgreementResult {
+	position := coreTypes.Position{Round: round, Height: 13}
+	return &coreTypes.AgreementResult{
+		BlockHash: coreCommon.Hash{9, 9, 9},
+		Position:  position,
+		Votes: []coreTypes.Vote{{
+			VoteHeader: coreTypes.VoteHeader{
+				ProposerID: coreTypes.NodeID{coreCommon.Hash{1, 2, 3}},
+				Type:       coreTypes.VoteCom,
+				BlockHash:  coreCommon.Hash{9, 9, 9},
+				Position:   position,
+			},
+			Signature: coreCrypto.Signature{
+				Type:      "123",
+				Signature: []byte("sig"),
+			},
+		}},
+		Randomness: []byte{9, 4, 8, 7},
+	}
+}
+
+func TestAggregatedAgreement(t *testing.T) {
+	// Agreement results without threshold signature keep their votes.
+	agreement := newTestAgreement(dexCore.DKGDelayRound - 1)
+	if aggregated := aggregatedAgreement(agreement); aggregated != agreement {
+		t.Errorf("agreement result before DKGDelayRound aggregated")
+	}
+
+	agreement = newTestAgreement(dexCore.DKGDelayRound)
+	aggregated := aggregatedAgreement(agreement)
+	if len(aggregated.Votes) != 0 {
+		t.Errorf("aggregated agreement result has %d votes", len(aggregated.Votes))
+	}
+	if len(agreement.Votes) != 1 {
+		t.Errorf("original agreement result modified")
+	}
+	if err := dexCore.VerifyAgreementResult(aggregated, nil); err != nil {
+		t.Errorf("aggregated agreement result rejected by the core: %v", err)
+	}
+
+	verifier := &testTSigVerifier{randomness: agreement.Randomness}
+	if err := verifyAgreementTSig(verifier, aggregated); err != nil {
+		t.Errorf("valid threshold signature rejected: %v", err)
+	}
+	aggregated.Randomness = []byte{1, 2, 3}
+	if err := verifyAgreementTSig(verifier, aggregated); err != errInvalidAgreementTSig {
+		t.Errorf("invalid threshold signature error mismatch: got %v, want %v", err, errInvalidAgreementTSig)
+	}
+}
+
+func TestSendAggregatedAgreement(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.SetReceiveCoreMessage(true)
+	pm.aggregateAgreements = true
+
+	p, _ := newTestPeer("peer", dex64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	agreement := newTestAgreement(dexCore.DKGDelayRound)
+	waitForRegister(pm, 1)
+	pm.BroadcastAgreementResult(agreement)
+	msg, err := p.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("%v: read error: %v", p.Peer, err)
+	} else if msg.Code != AgreementMsg {
+		t.Fatalf("%v: got code %d, want %d", p.Peer, msg.Code, AgreementMsg)
+	}
+	var a coreTypes.AgreementResult
+	if err := msg.Decode(&a); err != nil {
+		t.Fatalf("%v: %v", p.Peer, err)
+	}
+	if len(a.Votes) != 0 {
+		t.Errorf("agreement result sent with %d votes", len(a.Votes))
+	}
+	if !reflect.DeepEqual(a.Randomness, agreement.Randomness) {
+		t.Errorf("randomness mismatch")
+	}
+}
+
+func TestRecvAggregatedAgreement(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.SetReceiveCoreMessage(true)
+	agreement := aggregatedAgreement(newTestAgreement(dexCore.DKGDelayRound))
+	verifier := &testTSigVerifier{randomness: agreement.Randomness}
+	pm.tsigVerifiers = verifier
+
+	p, _ := newTestPeer("peer", dex64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	// The copies of a verified agreement result are not verified again.
+	for i := 0; i < 2; i++ {
+		if err := p2p.Send(p.app, AgreementMsg, agreement); err != nil {
+			t.Fatalf("send error: %v", err)
+		}
+		select {
+		case msg := <-pm.ReceiveChan():
+			if a := msg.Payload.(*coreTypes.AgreementResult); a.BlockHash != agreement.BlockHash {
+				t.Errorf("agreement mismatch")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no agreement received within 1 seconds")
+		}
+	}
+	if verifier.verified != 1 {
+		t.Errorf("verified agreement results mismatch: have %d, want 1", verifier.verified)
+	}
+
+	// Agreement results with an invalid threshold signature are dropped, and
+	// their sender penalized.
+	agreement.Position.Height++
+	agreement.Randomness = []byte{1, 2, 3}
+	if err := p2p.Send(p.app, AgreementMsg, agreement); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case <-pm.ReceiveChan():
+		t.Errorf("invalid agreement received")
+	case <-time.After(100 * time.Millisecond):
+	}
+	scores := pm.scores.peerScores()
+	if len(scores) != 1 || scores[0].ID != p.id || scores[0].Offenses[offenseInvalidAgreement.String()] != 1 {
+		t.Errorf("sender not penalized: %v", scores)
+	}
+}
@@ -25,6 +25,8 @@ import (
 	"os"
 	"strings"
 
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core"
@@ -72,6 +74,43 @@ func (api *PrivateAdminAPI) ExportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// RecoveryStatus returns the state of the watch cat and of the votes for
+// skipping blocks on the recovery network.
+func (api *PrivateAdminAPI) RecoveryStatus() *RecoveryStatus {
+	status := api.dex.recovery.Status()
+	status.WatchCat = api.dex.bp.WatchCatStatus()
+	return status
+}
+
+// ProposeSkipBlock votes on the recovery network for skipping the block at
+// the given height, as the watch cat does when the consensus core stalls.
+func (api *PrivateAdminAPI) ProposeSkipBlock(height hexutil.Uint64) (*SkipBlockStatus, error) {
+	return api.dex.recovery.ProposeSkipBlockManually(uint64(height))
+}
+
+// PeerBans returns the peers currently banned for misbehaving.
+func (api *PrivateAdminAPI) PeerBans() []*PeerBan {
+	return api.dex.protocolManager.scores.list()
+}
+
+// PeerScores returns the penalty scores of the peers which misbehaved
+// recently.
+func (api *PrivateAdminAPI) PeerScores() []*PeerScore {
+	return api.dex.protocolManager.scores.peerScores()
+}
+
+// Unban lifts the ban of the given peer and resets its score. It returns
+// whether the peer was banned.
+func (api *PrivateAdminAPI) Unban(id string) bool {
+	return api.dex.protocolManager.scores.unban(id)
+}
+
+// ClearBans lifts the bans of all peers and returns the number of peers
+// unbanned.
+func (api *PrivateAdminAPI) ClearBans() int {
+	return api.dex.protocolManager.scores.clearBans()
+}
+
 func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	for _, b := range bs {
 		if !chain.HasBlock(b.Hash(), b.NumberU64()) {
@@ -220,6 +259,16 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// VerifyBlock dry-runs the verification of an RLP encoded consensus block
+// against the current pending state, and reports every check it fails.
+func (api *PrivateDebugAPI) VerifyBlock(ctx context.Context, blockRlp hexutil.Bytes) (*BlockVerdict, error) {
+	var block coreTypes.Block
+	if err := rlp.DecodeBytes(blockRlp, &block); err != nil {
+		return nil, fmt.Errorf("could not decode block: %v", err)
+	}
+	return api.dex.app.verifyBlock(&block, true), nil
+}
+
 // StorageRangeResult is the result of a debug_storageRangeAt API call.
 type StorageRangeResult struct {
 	Storage storageMap   `json:"storage"`
@@ -0,0 +1,395 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"fmt"
+	"math/big"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+// PublicGovernanceAPI provides access to the governance contract state of
+// past and current rounds. Methods taking a round default to the round of
+// the current block if none is given.
+type PublicGovernanceAPI struct {
+	dex *Dexon
+}
+
+// NewPublicGovernanceAPI creates a new governance state API.
+func NewPublicGovernanceAPI(dex *Dexon) *PublicGovernanceAPI {
+	return &PublicGovernanceAPI{dex: dex}
+}
+
+// GovernanceNode is a node registered in the governance contract.
+type GovernanceNode struct {
+	Owner              common.Address `json:"owner"`
+	NodeKeyAddress     common.Address `json:"nodeKeyAddress"`
+	PublicKey          hexutil.Bytes  `json:"publicKey"`
+	Staked             *hexutil.Big   `json:"staked"`
+	Fined              *hexutil.Big   `json:"fined"`
+	Name               string         `json:"name"`
+	Email              string         `json:"email"`
+	Location           string         `json:"location"`
+	Url                string         `json:"url"`
+	Unstaked           *hexutil.Big   `json:"unstaked"`
+	UnstakedAt         *hexutil.Big   `json:"unstakedAt"`
+	LastProposedHeight *hexutil.Big   `json:"lastProposedHeight"`
+
+	// Qualified reports whether the node has no unpaid fine and stakes at
+	// least the minimum stake, which makes it eligible for the notary set.
+	Qualified bool `json:"qualified"`
+}
+
+// GovernanceNotary is a member of the notary set of a round.
+type GovernanceNotary struct {
+	NodeKeyAddress common.Address `json:"nodeKeyAddress"`
+	Owner          common.Address `json:"owner"`
+	PublicKey      hexutil.Bytes  `json:"publicKey"`
+}
+
+// GovernanceDKGComplaint is a complaint filed during the DKG of a round.
+type GovernanceDKGComplaint struct {
+	Proposer common.Address `json:"proposer"`
+	Target   common.Address `json:"target"`
+
+	// IsNack is set if the complaint reports a missing private share rather
+	// than an invalid one.
+	IsNack bool `json:"isNack"`
+}
+
+// GovernanceDKGParticipant is the DKG progress of a notary set member.
+type GovernanceDKGParticipant struct {
+	NodeKeyAddress common.Address `json:"nodeKeyAddress"`
+	Owner          common.Address `json:"owner"`
+	MPK            bool           `json:"mpk"`
+	MPKReady       bool           `json:"mpkReady"`
+	Finalized      bool           `json:"finalized"`
+	Success        bool           `json:"success"`
+}
+
+// GovernanceDKGStatus is the state of the DKG protocol of a round.
+type GovernanceDKGStatus struct {
+	Round      hexutil.Uint64 `json:"round"`
+	ResetCount hexutil.Uint64 `json:"resetCount"`
+
+	MPKCount       hexutil.Uint64 `json:"mpkCount"`
+	MPKReadyCount  hexutil.Uint64 `json:"mpkReadyCount"`
+	FinalizedCount hexutil.Uint64 `json:"finalizedCount"`
+	SuccessCount   hexutil.Uint64 `json:"successCount"`
+
+	MPKReady  bool `json:"mpkReady"`
+	Finalized bool `json:"finalized"`
+	Success   bool `json:"success"`
+
+	Complaints   []*GovernanceDKGComplaint   `json:"complaints"`
+	Participants []*GovernanceDKGParticipant `json:"participants"`
+}
+
+// GetRound returns the round of the current block.
+func (api *PublicGovernanceAPI) GetRound() hexutil.Uint64 {
+	return hexutil.Uint64(api.dex.BlockChain().CurrentBlock().Round())
+}
+
+// GetRoundHeight returns the height of the first block of the round.
+func (api *PublicGovernanceAPI) GetRoundHeight(round *hexutil.Uint64) (hexutil.Uint64, error) {
+	height, err := api.roundHeight(api.round(round))
+	return hexutil.Uint64(height), err
+}
+
+// GetConfiguration returns the governance configuration in effect during the
+// round.
+func (api *PublicGovernanceAPI) GetConfiguration(round *hexutil.Uint64) (*params.DexconConfig, error) {
+	s, err := api.configState(api.round(round))
+	if err != nil {
+		return nil, err
+	}
+	return s.Configuration(), nil
+}
+
+// GetNodes returns the nodes registered at the beginning of the round.
+func (api *PublicGovernanceAPI) GetNodes(round *hexutil.Uint64) ([]*GovernanceNode, error) {
+	s, err := api.roundState(api.round(round))
+	if err != nil {
+		return nil, err
+	}
+	minStake := s.MinStake()
+	nodes := []*GovernanceNode{}
+	for _, n := range s.Nodes() {
+		node := &GovernanceNode{
+			Owner:              n.Owner,
+			PublicKey:          n.PublicKey,
+			Staked:             (*hexutil.Big)(n.Staked),
+			Fined:              (*hexutil.Big)(n.Fined),
+			Name:               n.Name,
+			Email:              n.Email,
+			Location:           n.Location,
+			Url:                n.Url,
+			Unstaked:           (*hexutil.Big)(n.Unstaked),
+			UnstakedAt:         (*hexutil.Big)(n.UnstakedAt),
+			LastProposedHeight: (*hexutil.Big)(s.LastProposedHeight(n.Owner)),
+			Qualified:          n.Fined.Sign() == 0 && n.Staked.Cmp(minStake) >= 0,
+		}
+		if pk, err := crypto.UnmarshalPubkey(n.PublicKey); err == nil {
+			node.NodeKeyAddress = crypto.PubkeyToAddress(*pk)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// GetCRS returns the common reference string of the round.
+func (api *PublicGovernanceAPI) GetCRS(round *hexutil.Uint64) (common.Hash, error) {
+	return api.crs(api.round(round))
+}
+
+// GetNotarySet returns the notary set of the round.
+func (api *PublicGovernanceAPI) GetNotarySet(round *hexutil.Uint64) ([]*GovernanceNotary, error) {
+	r := api.round(round)
+	s, err := api.configState(r)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := api.notarySet(r)
+	if err != nil {
+		return nil, err
+	}
+	notaries := []*GovernanceNotary{}
+	for _, id := range ids {
+		addr := vm.IdToAddress(id)
+		notary := &GovernanceNotary{NodeKeyAddress: addr}
+		if offset := s.NodesOffsetByNodeKeyAddress(addr); offset.Sign() >= 0 {
+			n := s.Node(offset)
+			notary.Owner = n.Owner
+			notary.PublicKey = n.PublicKey
+		}
+		notaries = append(notaries, notary)
+	}
+	return notaries, nil
+}
+
+// GetDKGStatus returns the progress of the DKG protocol of the round. Only
+// rounds up to the one the DKG is currently running for are available.
+func (api *PublicGovernanceAPI) GetDKGStatus(round *hexutil.Uint64) (*GovernanceDKGStatus, error) {
+	r := api.round(round)
+	head, err := api.headState()
+	if err != nil {
+		return nil, err
+	}
+	dkgRound := head.DKGRound().Uint64()
+	if r > dkgRound {
+		return nil, fmt.Errorf("DKG of round %d not started, current DKG round %d",
+			r, dkgRound)
+	}
+	s := head
+	if r < dkgRound {
+		if s, err = api.roundState(r); err != nil {
+			return nil, err
+		}
+	}
+	cs, err := api.configState(r)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := api.notarySet(r)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &coreTypes.Config{NotarySetSize: uint32(cs.NotarySetSize().Uint64())}
+	threshold := uint64(coreUtils.GetDKGThreshold(config))
+	status := &GovernanceDKGStatus{
+		Round:          hexutil.Uint64(r),
+		ResetCount:     hexutil.Uint64(head.DKGResetCount(new(big.Int).SetUint64(r)).Uint64()),
+		MPKCount:       hexutil.Uint64(s.LenDKGMasterPublicKeys().Uint64()),
+		MPKReadyCount:  hexutil.Uint64(s.DKGMPKReadysCount().Uint64()),
+		FinalizedCount: hexutil.Uint64(s.DKGFinalizedsCount().Uint64()),
+		SuccessCount:   hexutil.Uint64(s.DKGSuccessesCount().Uint64()),
+		Complaints:     []*GovernanceDKGComplaint{},
+		Participants:   []*GovernanceDKGParticipant{},
+	}
+	status.MPKReady = uint64(status.MPKReadyCount) >= threshold
+	status.Finalized = uint64(status.FinalizedCount) >= threshold
+	status.Success = uint64(status.SuccessCount) >=
+		uint64(coreUtils.GetDKGValidThreshold(config))
+
+	for _, c := range s.DKGComplaintItems() {
+		status.Complaints = append(status.Complaints, &GovernanceDKGComplaint{
+			Proposer: vm.IdToAddress(c.ProposerID),
+			Target:   vm.IdToAddress(c.PrivateShare.ProposerID),
+			IsNack:   c.IsNack(),
+		})
+	}
+	for _, id := range ids {
+		addr := vm.IdToAddress(id)
+		p := &GovernanceDKGParticipant{
+			NodeKeyAddress: addr,
+			MPK:            s.DKGMasterPublicKeyOffset(vm.Bytes32(id.Hash)).Sign() >= 0,
+			MPKReady:       s.DKGMPKReady(addr),
+			Finalized:      s.DKGFinalized(addr),
+			Success:        s.DKGSuccess(addr),
+		}
+		if offset := cs.NodesOffsetByNodeKeyAddress(addr); offset.Sign() >= 0 {
+			p.Owner = cs.Node(offset).Owner
+		}
+		status.Participants = append(status.Participants, p)
+	}
+	return status, nil
+}
+
+// round returns the requested round, or the round of the current block if
+// none is given.
+func (api *PublicGovernanceAPI) round(round *hexutil.Uint64) uint64 {
+	if round == nil {
+		return api.dex.BlockChain().CurrentBlock().Round()
+	}
+	return uint64(*round)
+}
+
+func (api *PublicGovernanceAPI) headState() (*vm.GovernanceState, error) {
+	s, err := api.dex.BlockChain().State()
+	if err != nil {
+		return nil, err
+	}
+	return &vm.GovernanceState{StateDB: s}, nil
+}
+
+// roundHeight returns the height of the first block of a round that has
+// already started.
+func (api *PublicGovernanceAPI) roundHeight(round uint64) (uint64, error) {
+	if current := api.dex.BlockChain().CurrentBlock().Round(); round > current {
+		return 0, fmt.Errorf("round %d not reached, current round %d", round, current)
+	}
+	head, err := api.headState()
+	if err != nil {
+		return 0, err
+	}
+	height := head.RoundHeight(new(big.Int).SetUint64(round)).Uint64()
+	if round != 0 && height == 0 {
+		return 0, fmt.Errorf("height of round %d not recorded", round)
+	}
+	return height, nil
+}
+
+// roundState returns the governance state after the first block of the
+// round. Unlike core.Governance it reports missing state as an error rather
+// than panicking.
+func (api *PublicGovernanceAPI) roundState(round uint64) (*vm.GovernanceState, error) {
+	height, err := api.roundHeight(round)
+	if err != nil {
+		return nil, err
+	}
+	bc := api.dex.BlockChain()
+	header := bc.GetHeaderByNumber(height)
+	if header == nil {
+		return nil, fmt.Errorf("header at %d not exists", height)
+	}
+	s, err := bc.StateAt(header.Root)
+	if err != nil {
+		return nil, fmt.Errorf("state of round %d not available: %v", round, err)
+	}
+	return &vm.GovernanceState{StateDB: s}, nil
+}
+
+// configState returns the governance state holding the configuration of the
+// round.
+func (api *PublicGovernanceAPI) configState(round uint64) (*vm.GovernanceState, error) {
+	if round < dexCore.ConfigRoundShift {
+		return api.roundState(0)
+	}
+	return api.roundState(round - dexCore.ConfigRoundShift)
+}
+
+func (api *PublicGovernanceAPI) crs(round uint64) (common.Hash, error) {
+	if round <= dexCore.DKGDelayRound {
+		s, err := api.roundState(0)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		crs := s.CRS()
+		for i := uint64(0); i < round; i++ {
+			crs = crypto.Keccak256Hash(crs[:])
+		}
+		return crs, nil
+	}
+	head, err := api.headState()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	crsRound := head.CRSRound().Uint64()
+	if round > crsRound {
+		return common.Hash{}, fmt.Errorf("CRS of round %d not proposed, current CRS round %d",
+			round, crsRound)
+	}
+	if round == crsRound {
+		return head.CRS(), nil
+	}
+	s, err := api.roundState(round)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return s.CRS(), nil
+}
+
+// notarySet computes the notary set of the round the same way the consensus
+// core does. It does not go through the node set cache of the governance, as
+// querying old rounds would evict the rounds the consensus core is using.
+func (api *PublicGovernanceAPI) notarySet(round uint64) ([]coreTypes.NodeID, error) {
+	s, err := api.configState(round)
+	if err != nil {
+		return nil, err
+	}
+	crs, err := api.crs(round)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		nodeSet = coreTypes.NewNodeSet()
+		nodeIDs []coreTypes.NodeID
+	)
+	for _, n := range s.QualifiedNodes() {
+		pk, err := coreEcdsa.NewPublicKeyFromByteSlice(n.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		id := coreTypes.NewNodeID(pk)
+		nodeSet.Add(id)
+		nodeIDs = append(nodeIDs, id)
+	}
+	notarySet := nodeSet.GetSubSet(int(s.NotarySetSize().Uint64()),
+		coreTypes.NewNotarySetTarget(coreCommon.Hash(crs)))
+
+	// Keep the registration order so that results are stable.
+	ids := make([]coreTypes.NodeID, 0, len(notarySet))
+	for _, id := range nodeIDs {
+		if _, ok := notarySet[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
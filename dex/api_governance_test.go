@@ -0,0 +1,104 @@
+package dex
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+func TestPublicGovernanceAPI(t *testing.T) {
+	masterKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	dex, _, err := newDexon(masterKey, 0)
+	if err != nil {
+		t.Fatalf("failed to create dexon: %v", err)
+	}
+	var (
+		api     = NewPublicGovernanceAPI(dex)
+		owner   = crypto.PubkeyToAddress(masterKey.PublicKey)
+		round0  = hexutil.Uint64(0)
+		round1  = hexutil.Uint64(1)
+		round10 = hexutil.Uint64(10)
+	)
+
+	if height, err := api.GetRoundHeight(nil); err != nil || height != 0 {
+		t.Errorf("round height mismatch: have %d (%v), want 0", height, err)
+	}
+	if _, err := api.GetRoundHeight(&round1); err == nil {
+		t.Errorf("height of future round returned")
+	}
+
+	config, err := api.GetConfiguration(&round0)
+	if err != nil {
+		t.Fatalf("failed to get configuration: %v", err)
+	}
+	if config.RoundLength != 600 || config.BlockGasLimit != 2000000 {
+		t.Errorf("configuration mismatch: %+v", config)
+	}
+
+	nodes, err := api.GetNodes(&round0)
+	if err != nil {
+		t.Fatalf("failed to get nodes: %v", err)
+	}
+	var master *GovernanceNode
+	for _, n := range nodes {
+		if n.Owner == owner {
+			master = n
+		}
+	}
+	if master == nil || master.NodeKeyAddress != owner {
+		t.Fatalf("master node mismatch: %+v", master)
+	}
+	// The master node stakes less than the minimum stake.
+	if master.Qualified {
+		t.Errorf("under-staked node reported qualified")
+	}
+
+	crs0, err := api.GetCRS(&round0)
+	if err != nil {
+		t.Fatalf("failed to get CRS: %v", err)
+	}
+	crs1, err := api.GetCRS(&round1)
+	if err != nil {
+		t.Fatalf("failed to get CRS: %v", err)
+	}
+	if crs1 != crypto.Keccak256Hash(crs0[:]) {
+		t.Errorf("CRS mismatch: have %x, want hash of %x", crs1, crs0)
+	}
+	if _, err := api.GetCRS(&round10); err == nil {
+		t.Errorf("CRS of future round returned")
+	}
+
+	notaries, err := api.GetNotarySet(&round0)
+	if err != nil {
+		t.Fatalf("failed to get notary set: %v", err)
+	}
+	want, err := dex.governance.NotarySet(0)
+	if err != nil {
+		t.Fatalf("failed to get notary set from governance: %v", err)
+	}
+	if len(notaries) == 0 || len(notaries) != len(want) {
+		t.Fatalf("notary set size mismatch: have %d, want %d", len(notaries), len(want))
+	}
+	for _, n := range notaries {
+		if _, ok := want[hex.EncodeToString(n.PublicKey)]; !ok {
+			t.Errorf("unexpected notary %x", n.NodeKeyAddress)
+		}
+	}
+
+	status, err := api.GetDKGStatus(&round0)
+	if err != nil {
+		t.Fatalf("failed to get DKG status: %v", err)
+	}
+	if len(status.Participants) != len(notaries) {
+		t.Errorf("DKG participants mismatch: have %d, want %d",
+			len(status.Participants), len(notaries))
+	}
+	if _, err := api.GetDKGStatus(&round10); err == nil {
+		t.Errorf("DKG status of future round returned")
+	}
+}
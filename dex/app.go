@@ -29,11 +29,13 @@ import (
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/event"
 	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/params"
 	"github.com/dexon-foundation/dexon/rlp"
 )
 
@@ -44,6 +46,7 @@ type DexconApp struct {
 	gov        *DexconGovernance
 	chainDB    ethdb.Database
 	config     *Config
+	policy     PayloadPolicy
 
 	finalizedBlockFeed event.Feed
 	scope              event.SubscriptionScope
@@ -66,6 +69,7 @@ func NewDexconApp(txPool *core.TxPool, blockchain *core.BlockChain, gov *DexconG
 		gov:             gov,
 		chainDB:         chainDB,
 		config:          config,
+		policy:          NewPayloadPolicy(config.PayloadPolicy),
 		confirmedBlocks: map[coreCommon.Hash]*blockInfo{},
 		addressNonce:    map[common.Address]uint64{},
 		addressCost:     map[common.Address]*big.Int{},
@@ -74,43 +78,6 @@ func NewDexconApp(txPool *core.TxPool, blockchain *core.BlockChain, gov *DexconG
 	}
 }
 
-// validateNonce check if nonce is in order and return first nonce of every address.
-func (d *DexconApp) validateNonce(txs types.Transactions) (map[common.Address]uint64, error) {
-	addressFirstNonce := map[common.Address]uint64{}
-	addressNonce := map[common.Address]uint64{}
-
-	for _, tx := range txs {
-		msg, err := tx.AsMessage(types.MakeSigner(d.blockchain.Config(), new(big.Int)))
-		if err != nil {
-			return nil, err
-		}
-
-		if _, exist := addressFirstNonce[msg.From()]; exist {
-			if addressNonce[msg.From()]+1 != msg.Nonce() {
-				return nil, fmt.Errorf("address nonce check error: expect %v actual %v",
-					addressNonce[msg.From()]+1, msg.Nonce())
-			}
-			addressNonce[msg.From()] = msg.Nonce()
-		} else {
-			addressNonce[msg.From()] = msg.Nonce()
-			addressFirstNonce[msg.From()] = msg.Nonce()
-		}
-	}
-	return addressFirstNonce, nil
-}
-
-// validateGasPrice checks if no gas price is lower than minGasPrice defined in
-// governance contract.
-func (d *DexconApp) validateGasPrice(txs types.Transactions, round uint64) bool {
-	minGasPrice := d.gov.MinGasPrice(round)
-	for _, tx := range txs {
-		if minGasPrice.Cmp(tx.GasPrice()) > 0 {
-			return false
-		}
-	}
-	return true
-}
-
 // PreparePayload is called when consensus core is preparing payload for block.
 func (d *DexconApp) PreparePayload(position coreTypes.Position) (payload []byte, err error) {
 	// softLimit limits the runtime of inner call to preparePayload.
@@ -180,25 +147,15 @@ func (d *DexconApp) preparePayload(ctx context.Context, position coreTypes.Posit
 		return
 	}
 
-	blockGasLimit := new(big.Int).SetUint64(d.gov.DexconConfiguration(position.Round).BlockGasLimit)
+	blockGasLimit := d.gov.DexconConfiguration(position.Round).BlockGasLimit
 	minGasPrice := d.gov.DexconConfiguration(position.Round).MinGasPrice
-	blockGasUsed := new(big.Int)
+	blockGasUsed := uint64(0)
 	allTxs := make([]*types.Transaction, 0, 10000)
 
-addressMap:
+	// Skip the transactions already included in confirmed but undelivered
+	// blocks, and track the balance left to each sender after them.
+	balances := make(map[common.Address]*big.Int, len(txsMap))
 	for address, txs := range txsMap {
-		select {
-		case <-ctx.Done():
-			break addressMap
-		default:
-		}
-
-		balance := state.GetBalance(address)
-		cost, exist := d.addressCost[address]
-		if exist {
-			balance = new(big.Int).Sub(balance, cost)
-		}
-
 		var expectNonce uint64
 		lastConfirmedNonce, exist := d.addressNonce[address]
 		if !exist {
@@ -207,44 +164,82 @@ addressMap:
 			expectNonce = lastConfirmedNonce + 1
 		}
 
-		if len(txs) == 0 {
+		// Warning: the pending tx will also affect by syncing, so startIndex maybe negative
+		if len(txs) == 0 || expectNonce < txs[0].Nonce() ||
+			expectNonce-txs[0].Nonce() >= uint64(len(txs)) {
+			delete(txsMap, address)
 			continue
 		}
+		txsMap[address] = txs[expectNonce-txs[0].Nonce():]
+
+		balance := state.GetBalance(address)
+		if cost, exist := d.addressCost[address]; exist {
+			balance = new(big.Int).Sub(balance, cost)
+		}
+		balances[address] = balance
+	}
 
-		firstNonce := txs[0].Nonce()
-		startIndex := int(expectNonce - firstNonce)
+	// Pick transactions in the order offered by the payload policy.
+	signer := types.NewEIP155Signer(d.blockchain.Config().ChainID)
+	candidates := d.policy.Transactions(signer, txsMap)
 
-		// Warning: the pending tx will also affect by syncing, so startIndex maybe negative
-		for i := startIndex; i >= 0 && i < len(txs); i++ {
-			tx := txs[i]
-			if minGasPrice.Cmp(tx.GasPrice()) > 0 {
-				log.Error("Invalid gas price minGas(%v) > get(%v)", minGasPrice, tx.GasPrice())
-				break
-			}
+txLoop:
+	for {
+		select {
+		case <-ctx.Done():
+			break txLoop
+		default:
+		}
 
-			intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true)
-			if err != nil {
-				log.Error("Failed to calculate intrinsic gas", "error", err)
-				return nil, fmt.Errorf("calculate intrinsic gas error: %v", err)
-			}
-			if tx.Gas() < intrGas {
-				log.Error("Intrinsic gas too low", "txHash", tx.Hash().String())
-				break
-			}
+		if blockGasLimit-blockGasUsed < params.TxGas {
+			break
+		}
+		tx := candidates.Peek()
+		if tx == nil {
+			break
+		}
+		address, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Error("Failed to get sender", "txHash", tx.Hash().String(), "error", err)
+			candidates.Pop()
+			continue
+		}
 
-			balance = new(big.Int).Sub(balance, tx.Cost())
-			if balance.Cmp(big.NewInt(0)) < 0 {
-				log.Warn("Insufficient funds for gas * price + value", "txHash", tx.Hash().String())
-				break
-			}
+		// Once a transaction is rejected, the following ones of the same
+		// sender can not be included either.
+		if minGasPrice.Cmp(tx.GasPrice()) > 0 {
+			log.Error("Invalid gas price", "minGasPrice", minGasPrice, "gasPrice", tx.GasPrice())
+			candidates.Pop()
+			continue
+		}
 
-			blockGasUsed = new(big.Int).Add(blockGasUsed, big.NewInt(int64(tx.Gas())))
-			if blockGasUsed.Cmp(blockGasLimit) > 0 {
-				break addressMap
-			}
+		intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true)
+		if err != nil {
+			log.Error("Failed to calculate intrinsic gas", "error", err)
+			return nil, fmt.Errorf("calculate intrinsic gas error: %v", err)
+		}
+		if tx.Gas() < intrGas {
+			log.Error("Intrinsic gas too low", "txHash", tx.Hash().String())
+			candidates.Pop()
+			continue
+		}
 
-			allTxs = append(allTxs, tx)
+		balance := new(big.Int).Sub(balances[address], tx.Cost())
+		if balance.Cmp(big.NewInt(0)) < 0 {
+			log.Warn("Insufficient funds for gas * price + value", "txHash", tx.Hash().String())
+			candidates.Pop()
+			continue
 		}
+
+		if blockGasUsed+tx.Gas() > blockGasLimit {
+			candidates.Pop()
+			continue
+		}
+
+		balances[address] = balance
+		blockGasUsed += tx.Gas()
+		allTxs = append(allTxs, tx)
+		candidates.Shift()
 	}
 
 	return rlp.EncodeToBytes(&allTxs)
@@ -272,142 +267,267 @@ func (d *DexconApp) PrepareWitness(consensusHeight uint64) (witness coreTypes.Wi
 	}, nil
 }
 
+// BlockVerdict is the detailed result of verifying a block.
+type BlockVerdict struct {
+	Status  coreTypes.BlockVerifyStatus `json:"-"`
+	Verdict string                      `json:"verdict"`
+	Reasons []string                    `json:"reasons"`
+
+	WitnessHeight uint64      `json:"witnessHeight"`
+	WitnessHash   common.Hash `json:"witnessHash"`
+	WitnessValid  bool        `json:"witnessValid"`
+
+	NonceGaps            []*NonceGap            `json:"nonceGaps"`
+	GasPriceFailures     []*GasPriceFailure     `json:"gasPriceFailures"`
+	IntrinsicGasFailures []*IntrinsicGasFailure `json:"intrinsicGasFailures"`
+	BalanceShortfalls    []*BalanceShortfall    `json:"balanceShortfalls"`
+	GasLimitOverrun      *GasLimitOverrun       `json:"gasLimitOverrun"`
+}
+
+// NonceGap is a transaction whose nonce differs from the one expected.
+type NonceGap struct {
+	Sender   common.Address `json:"sender"`
+	TxHash   common.Hash    `json:"txHash"`
+	Expected uint64         `json:"expected"`
+	Nonce    uint64         `json:"nonce"`
+}
+
+// GasPriceFailure is a transaction priced below the minimum gas price.
+type GasPriceFailure struct {
+	TxHash      common.Hash  `json:"txHash"`
+	GasPrice    *hexutil.Big `json:"gasPrice"`
+	MinGasPrice *hexutil.Big `json:"minGasPrice"`
+}
+
+// IntrinsicGasFailure is a transaction with less gas than its intrinsic gas.
+type IntrinsicGasFailure struct {
+	TxHash    common.Hash    `json:"txHash"`
+	Gas       hexutil.Uint64 `json:"gas"`
+	Intrinsic hexutil.Uint64 `json:"intrinsic"`
+}
+
+// BalanceShortfall is a transaction costing more than the balance left to
+// its sender.
+type BalanceShortfall struct {
+	Sender  common.Address `json:"sender"`
+	TxHash  common.Hash    `json:"txHash"`
+	Balance *hexutil.Big   `json:"balance"`
+	Cost    *hexutil.Big   `json:"cost"`
+}
+
+// GasLimitOverrun lists the transactions exceeding the block gas limit.
+type GasLimitOverrun struct {
+	GasLimit hexutil.Uint64 `json:"gasLimit"`
+	GasUsed  hexutil.Uint64 `json:"gasUsed"`
+	TxHashes []common.Hash  `json:"txHashes"`
+}
+
+func (v *BlockVerdict) retryLater(format string, args ...interface{}) {
+	v.Status = coreTypes.VerifyRetryLater
+	v.Reasons = append(v.Reasons, fmt.Sprintf(format, args...))
+}
+
+// invalid marks the block invalid, unless it has to be retried later anyway.
+func (v *BlockVerdict) invalid(format string, args ...interface{}) {
+	if v.Status == coreTypes.VerifyOK {
+		v.Status = coreTypes.VerifyInvalidBlock
+	}
+	v.Reasons = append(v.Reasons, fmt.Sprintf(format, args...))
+}
+
+func (v *BlockVerdict) finish() *BlockVerdict {
+	switch v.Status {
+	case coreTypes.VerifyOK:
+		v.Verdict = "ok"
+	case coreTypes.VerifyRetryLater:
+		v.Verdict = "retry later"
+	case coreTypes.VerifyInvalidBlock:
+		v.Verdict = "invalid"
+	}
+	return v
+}
+
 // VerifyBlock verifies if the payloads are valid.
 func (d *DexconApp) VerifyBlock(block *coreTypes.Block) coreTypes.BlockVerifyStatus {
-	var witnessBlockHash common.Hash
-	err := rlp.DecodeBytes(block.Witness.Data, &witnessBlockHash)
-	if err != nil {
-		log.Error("Failed to RLP decode witness data", "error", err)
-		return coreTypes.VerifyInvalidBlock
+	verdict := d.verifyBlock(block, false)
+	switch verdict.Status {
+	case coreTypes.VerifyRetryLater:
+		log.Debug("Block verification retry later", "reasons", verdict.Reasons)
+	case coreTypes.VerifyInvalidBlock:
+		log.Error("Invalid block", "position", block.Position, "reasons", verdict.Reasons)
+	}
+	return verdict.Status
+}
+
+// verifyBlock verifies the block against the current pending state without
+// modifying it. Unlike VerifyBlock's status, the verdict reports every
+// transaction failing the checks. Blocks to be retried later are returned
+// without checking the payload, unless full is set.
+func (d *DexconApp) verifyBlock(block *coreTypes.Block, full bool) *BlockVerdict {
+	v := &BlockVerdict{
+		Status:        coreTypes.VerifyOK,
+		WitnessHeight: block.Witness.Height,
+	}
+	if err := rlp.DecodeBytes(block.Witness.Data, &v.WitnessHash); err != nil {
+		v.invalid("failed to RLP decode witness data: %v", err)
+		return v.finish()
 	}
 
 	// Validate witness height.
-	if d.blockchain.CurrentBlock().NumberU64() < block.Witness.Height {
-		log.Debug("Current height < witness height")
-		return coreTypes.VerifyRetryLater
+	if current := d.blockchain.CurrentBlock().NumberU64(); current < block.Witness.Height {
+		v.retryLater("current height %d < witness height %d", current, block.Witness.Height)
+		return v.finish()
 	}
 
 	b := d.blockchain.GetBlockByNumber(block.Witness.Height)
 	if b == nil {
-		log.Error("Can not get block by height", "height", block.Witness.Height)
-		return coreTypes.VerifyInvalidBlock
+		v.invalid("can not get block by height %d", block.Witness.Height)
+		return v.finish()
 	}
 
-	if b.Hash() != witnessBlockHash {
-		log.Error("Witness block hash not match",
-			"expect", b.Hash().String(), "got", witnessBlockHash.String())
-		return coreTypes.VerifyInvalidBlock
+	if b.Hash() != v.WitnessHash {
+		v.invalid("witness block hash not match: expect %s got %s",
+			b.Hash().String(), v.WitnessHash.String())
+		return v.finish()
 	}
 
-	_, err = d.blockchain.StateAt(b.Root())
-	if err != nil {
-		log.Error("Get state by root %v error: %v", b.Root(), err)
-		return coreTypes.VerifyInvalidBlock
+	if _, err := d.blockchain.StateAt(b.Root()); err != nil {
+		v.invalid("get state by root %v error: %v", b.Root(), err)
+		return v.finish()
 	}
+	v.WitnessValid = true
 
 	d.appMu.RLock()
 	defer d.appMu.RUnlock()
 
 	// deliver height + 1 = position height
 	if d.deliveredHeight+d.undeliveredNum+1 != block.Position.Height {
-		return coreTypes.VerifyRetryLater
+		v.retryLater("expected height %d but get %d",
+			d.deliveredHeight+d.undeliveredNum+1, block.Position.Height)
+		// A full verification still checks the payload against the current
+		// pending state, for the report only.
+		if !full {
+			return v.finish()
+		}
 	}
 
-	var transactions types.Transactions
 	if len(block.Payload) == 0 {
-		return coreTypes.VerifyOK
+		return v.finish()
 	}
 
 	deliveredBlock := d.blockchain.GetBlockByNumber(d.deliveredHeight)
 	state, err := d.blockchain.StateAt(deliveredBlock.Root())
 	if err != nil {
-		return coreTypes.VerifyInvalidBlock
-	}
-
-	err = rlp.DecodeBytes(block.Payload, &transactions)
-	if err != nil {
-		log.Error("Payload rlp decode", "error", err)
-		return coreTypes.VerifyInvalidBlock
+		v.invalid("get state by root %v error: %v", deliveredBlock.Root(), err)
+		return v.finish()
 	}
 
-	_, err = types.GlobalSigCache.Add(types.NewEIP155Signer(d.blockchain.Config().ChainID), transactions)
-	if err != nil {
-		log.Error("Failed to calculate sender", "error", err)
-		return coreTypes.VerifyInvalidBlock
-	}
-
-	addressNonce, err := d.validateNonce(transactions)
-	if err != nil {
-		log.Error("Validate nonce failed", "error", err)
-		return coreTypes.VerifyInvalidBlock
-	}
-	if !d.validateGasPrice(transactions, block.Position.Round) {
-		log.Error("Validate gas price failed")
-		return coreTypes.VerifyInvalidBlock
+	var transactions types.Transactions
+	if err := rlp.DecodeBytes(block.Payload, &transactions); err != nil {
+		v.invalid("payload rlp decode: %v", err)
+		return v.finish()
+	}
+
+	signer := types.NewEIP155Signer(d.blockchain.Config().ChainID)
+	// Senders are recovered in parallel and cached, the loop below only
+	// locates the offending transaction.
+	types.GlobalSigCache.Add(signer, transactions)
+	senders := make([]common.Address, len(transactions))
+	for i, tx := range transactions {
+		if senders[i], err = types.Sender(signer, tx); err != nil {
+			v.invalid("failed to calculate sender of %s: %v", tx.Hash().String(), err)
+			return v.finish()
+		}
 	}
 
-	for address, firstNonce := range addressNonce {
-		var expectNonce uint64
-		nonce, exist := d.addressNonce[address]
-		if exist {
-			expectNonce = nonce + 1
-		} else {
-			expectNonce = state.GetNonce(address)
+	// Expected nonces and balances in last state (including pending state).
+	var (
+		expectNonces     = map[common.Address]uint64{}
+		addressesBalance = map[common.Address]*big.Int{}
+	)
+	for _, address := range senders {
+		if _, exist := expectNonces[address]; exist {
+			continue
 		}
-
-		if expectNonce != firstNonce {
-			log.Error("Nonce check error", "expect", expectNonce, "firstNonce", firstNonce)
-			return coreTypes.VerifyInvalidBlock
+		if nonce, exist := d.addressNonce[address]; exist {
+			expectNonces[address] = nonce + 1
+		} else {
+			expectNonces[address] = state.GetNonce(address)
 		}
-	}
-
-	// Calculate balance in last state (including pending state).
-	addressesBalance := map[common.Address]*big.Int{}
-	for address := range addressNonce {
-		cost, exist := d.addressCost[address]
-		if exist {
+		if cost, exist := d.addressCost[address]; exist {
 			addressesBalance[address] = new(big.Int).Sub(state.GetBalance(address), cost)
 		} else {
 			addressesBalance[address] = state.GetBalance(address)
 		}
 	}
 
-	// Validate if balance is enough for TXs in this block.
-	blockGasLimit := new(big.Int).SetUint64(d.gov.DexconConfiguration(block.Position.Round).BlockGasLimit)
-	blockGasUsed := new(big.Int)
+	var (
+		minGasPrice   = d.gov.MinGasPrice(block.Position.Round)
+		blockGasLimit = d.gov.DexconConfiguration(block.Position.Round).BlockGasLimit
+		blockGasUsed  uint64
+	)
+	for i, tx := range transactions {
+		address := senders[i]
+		if nonce := tx.Nonce(); nonce != expectNonces[address] {
+			v.invalid("nonce check error: expect %d actual %d", expectNonces[address], nonce)
+			v.NonceGaps = append(v.NonceGaps, &NonceGap{
+				Sender:   address,
+				TxHash:   tx.Hash(),
+				Expected: expectNonces[address],
+				Nonce:    nonce,
+			})
+		}
+		expectNonces[address] = tx.Nonce() + 1
 
-	for _, tx := range transactions {
-		msg, err := tx.AsMessage(types.MakeSigner(d.blockchain.Config(), new(big.Int)))
-		if err != nil {
-			log.Error("Failed to convert tx to message", "error", err)
-			return coreTypes.VerifyInvalidBlock
+		if minGasPrice.Cmp(tx.GasPrice()) > 0 {
+			v.invalid("gas price %v lower than %v", tx.GasPrice(), minGasPrice)
+			v.GasPriceFailures = append(v.GasPriceFailures, &GasPriceFailure{
+				TxHash:      tx.Hash(),
+				GasPrice:    (*hexutil.Big)(tx.GasPrice()),
+				MinGasPrice: (*hexutil.Big)(minGasPrice),
+			})
 		}
-		balance := addressesBalance[msg.From()]
-		intrGas, err := core.IntrinsicGas(msg.Data(), msg.To() == nil, true)
+
+		intrGas, err := core.IntrinsicGas(tx.Data(), tx.To() == nil, true)
 		if err != nil {
-			log.Error("Failed to calculate intrinsic gas", "err", err)
-			return coreTypes.VerifyInvalidBlock
+			v.invalid("failed to calculate intrinsic gas: %v", err)
+			return v.finish()
 		}
 		if tx.Gas() < intrGas {
-			log.Error("Intrinsic gas too low", "txHash", tx.Hash().String(), "intrinsic", intrGas, "gas", tx.Gas())
-			return coreTypes.VerifyInvalidBlock
+			v.invalid("intrinsic gas too low: intrinsic %d gas %d", intrGas, tx.Gas())
+			v.IntrinsicGasFailures = append(v.IntrinsicGasFailures, &IntrinsicGasFailure{
+				TxHash:    tx.Hash(),
+				Gas:       hexutil.Uint64(tx.Gas()),
+				Intrinsic: hexutil.Uint64(intrGas),
+			})
 		}
 
-		balance = new(big.Int).Sub(balance, tx.Cost())
-		if balance.Cmp(big.NewInt(0)) < 0 {
-			log.Error("Insufficient funds for gas * price + value", "txHash", tx.Hash().String())
-			return coreTypes.VerifyInvalidBlock
+		balance := new(big.Int).Sub(addressesBalance[address], tx.Cost())
+		if balance.Sign() < 0 {
+			v.invalid("insufficient funds for gas * price + value")
+			v.BalanceShortfalls = append(v.BalanceShortfalls, &BalanceShortfall{
+				Sender:  address,
+				TxHash:  tx.Hash(),
+				Balance: (*hexutil.Big)(addressesBalance[address]),
+				Cost:    (*hexutil.Big)(tx.Cost()),
+			})
+		} else {
+			addressesBalance[address] = balance
 		}
 
-		blockGasUsed = new(big.Int).Add(blockGasUsed, new(big.Int).SetUint64(tx.Gas()))
-		if blockGasUsed.Cmp(blockGasLimit) > 0 {
-			log.Error("Reach block gas limit", "gasUsed", blockGasUsed)
-			return coreTypes.VerifyInvalidBlock
+		blockGasUsed += tx.Gas()
+		if blockGasUsed > blockGasLimit {
+			if v.GasLimitOverrun == nil {
+				v.invalid("reach block gas limit %d", blockGasLimit)
+				v.GasLimitOverrun = &GasLimitOverrun{GasLimit: hexutil.Uint64(blockGasLimit)}
+			}
+			v.GasLimitOverrun.TxHashes = append(v.GasLimitOverrun.TxHashes, tx.Hash())
 		}
-		addressesBalance[msg.From()] = balance
 	}
-
-	return coreTypes.VerifyOK
+	if v.GasLimitOverrun != nil {
+		v.GasLimitOverrun.GasUsed = hexutil.Uint64(blockGasUsed)
+	}
+	return v.finish()
 }
 
 // BlockDelivered is called when a block is add to the compaction chain.
@@ -578,6 +698,13 @@ func (d *DexconApp) getConfirmedBlockByHash(hash coreCommon.Hash) (*coreTypes.Bl
 	return info.block, info.txs
 }
 
+// SetPayloadPolicy replaces the policy used to pick payload transactions.
+func (d *DexconApp) SetPayloadPolicy(policy PayloadPolicy) {
+	d.appMu.Lock()
+	defer d.appMu.Unlock()
+	d.policy = policy
+}
+
 func (d *DexconApp) SubscribeNewFinalizedBlockEvent(
 	ch chan<- core.NewFinalizedBlockEvent) event.Subscription {
 	return d.scope.Track(d.finalizedBlockFeed.Subscribe(ch))
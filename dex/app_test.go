@@ -1,6 +1,7 @@
 package dex
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
@@ -2352,3 +2353,152 @@ func newDexon(masterKey *ecdsa.PrivateKey, accountNum int) (*Dexon, []*ecdsa.Pri
 
 	return dex, accounts, nil
 }
+
+func TestPreparePayloadByPrice(t *testing.T) {
+	masterKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Generate key fail: %v", err)
+	}
+
+	dex, keys, err := newDexon(masterKey, 2)
+	if err != nil {
+		t.Fatalf("New dexon fail: %v", err)
+	}
+
+	var (
+		signer   = types.NewEIP155Signer(dex.blockchain.Config().ChainID)
+		minPrice = dex.app.gov.GetHeadState().MinGasPrice()
+		to       = crypto.PubkeyToAddress(masterKey.PublicKey)
+	)
+	addTxs := func(key *ecdsa.PrivateKey, price *big.Int, count int) {
+		for nonce := 0; nonce < count; nonce++ {
+			tx := types.NewTransaction(uint64(nonce), to, big.NewInt(1), 21000, price, nil)
+			tx, err := types.SignTx(tx, signer, key)
+			if err != nil {
+				t.Fatalf("Sign tx fail: %v", err)
+			}
+			if err := dex.txPool.AddLocal(tx); err != nil {
+				t.Fatalf("Add tx fail: %v", err)
+			}
+		}
+	}
+	addTxs(keys[0], minPrice, 2)
+	addTxs(keys[1], new(big.Int).Mul(minPrice, big.NewInt(2)), 2)
+
+	payload, err := dex.app.PreparePayload(coreTypes.Position{Height: 1})
+	if err != nil {
+		t.Fatalf("Prepare payload fail: %v", err)
+	}
+	var txs types.Transactions
+	if err := rlp.DecodeBytes(payload, &txs); err != nil {
+		t.Fatalf("Decode payload fail: %v", err)
+	}
+	want := []struct {
+		key   *ecdsa.PrivateKey
+		nonce uint64
+	}{{keys[1], 0}, {keys[1], 1}, {keys[0], 0}, {keys[0], 1}}
+	if len(txs) != len(want) {
+		t.Fatalf("Payload size mismatch: have %d, want %d", len(txs), len(want))
+	}
+	for i, tx := range txs {
+		from, _ := types.Sender(signer, tx)
+		if from != crypto.PubkeyToAddress(want[i].key.PublicKey) || tx.Nonce() != want[i].nonce {
+			t.Errorf("tx %d mismatch: have %x nonce %d", i, from, tx.Nonce())
+		}
+	}
+}
+
+func TestVerifyBlockVerdict(t *testing.T) {
+	masterKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Generate key fail: %v", err)
+	}
+
+	dex, keys, err := newDexon(masterKey, 2)
+	if err != nil {
+		t.Fatalf("New dexon fail: %v", err)
+	}
+
+	var (
+		signer   = types.NewEIP155Signer(dex.blockchain.Config().ChainID)
+		minPrice = dex.app.gov.GetHeadState().MinGasPrice()
+		to       = crypto.PubkeyToAddress(masterKey.PublicKey)
+		txs      types.Transactions
+	)
+	addTx := func(key *ecdsa.PrivateKey, nonce uint64, value *big.Int, gas uint64, price *big.Int) {
+		tx, err := types.SignTx(types.NewTransaction(nonce, to, value, gas, price, nil), signer, key)
+		if err != nil {
+			t.Fatalf("Sign tx fail: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	lowPrice := new(big.Int).Sub(minPrice, big.NewInt(1))
+	addTx(keys[0], 0, big.NewInt(1), 21000, minPrice)
+	addTx(keys[0], 2, big.NewInt(1), 21000, minPrice)
+	addTx(keys[1], 0, big.NewInt(1), 21000, lowPrice)
+	addTx(keys[1], 1, math.BigPow(10, 18), 21000, minPrice)
+	addTx(masterKey, 0, big.NewInt(1), 2000000, minPrice)
+
+	witness, err := dex.app.PrepareWitness(0)
+	if err != nil {
+		t.Fatalf("Prepare witness fail: %v", err)
+	}
+	payload, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		t.Fatalf("Encode payload fail: %v", err)
+	}
+	block := &coreTypes.Block{
+		Position: coreTypes.Position{Height: 1},
+		Witness:  witness,
+		Payload:  payload,
+	}
+	blockRlp, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatalf("Encode block fail: %v", err)
+	}
+
+	verdict, err := NewPrivateDebugAPI(dex.chainConfig, dex).VerifyBlock(context.Background(), blockRlp)
+	if err != nil {
+		t.Fatalf("Verify block fail: %v", err)
+	}
+	if verdict.Status != coreTypes.VerifyInvalidBlock || verdict.Verdict != "invalid" {
+		t.Errorf("Verdict mismatch: have %v (%s)", verdict.Status, verdict.Verdict)
+	}
+	if status := dex.app.VerifyBlock(block); status != verdict.Status {
+		t.Errorf("Status mismatch: have %v, want %v", status, verdict.Status)
+	}
+	if !verdict.WitnessValid {
+		t.Errorf("Witness reported invalid: %v", verdict.Reasons)
+	}
+	if len(verdict.NonceGaps) != 1 || verdict.NonceGaps[0].TxHash != txs[1].Hash() ||
+		verdict.NonceGaps[0].Expected != 1 {
+		t.Errorf("Nonce gaps mismatch: %+v", verdict.NonceGaps)
+	}
+	if len(verdict.GasPriceFailures) != 1 || verdict.GasPriceFailures[0].TxHash != txs[2].Hash() {
+		t.Errorf("Gas price failures mismatch: %+v", verdict.GasPriceFailures)
+	}
+	if len(verdict.BalanceShortfalls) != 1 || verdict.BalanceShortfalls[0].TxHash != txs[3].Hash() {
+		t.Errorf("Balance shortfalls mismatch: %+v", verdict.BalanceShortfalls)
+	}
+	if verdict.GasLimitOverrun == nil || len(verdict.GasLimitOverrun.TxHashes) != 1 ||
+		verdict.GasLimitOverrun.TxHashes[0] != txs[4].Hash() {
+		t.Errorf("Gas limit overrun mismatch: %+v", verdict.GasLimitOverrun)
+	}
+
+	// A block ahead of the delivered height is retried later right away, but
+	// its payload is still reported by the dry-run.
+	block.Position.Height = 2
+	if status := dex.app.VerifyBlock(block); status != coreTypes.VerifyRetryLater {
+		t.Errorf("Status mismatch: have %v, want %v", status, coreTypes.VerifyRetryLater)
+	}
+	if blockRlp, err = rlp.EncodeToBytes(block); err != nil {
+		t.Fatalf("Encode block fail: %v", err)
+	}
+	verdict, err = NewPrivateDebugAPI(dex.chainConfig, dex).VerifyBlock(context.Background(), blockRlp)
+	if err != nil {
+		t.Fatalf("Verify block fail: %v", err)
+	}
+	if verdict.Status != coreTypes.VerifyRetryLater || len(verdict.NonceGaps) != 1 {
+		t.Errorf("Verdict mismatch: have %v (%s), nonce gaps %+v", verdict.Status, verdict.Verdict, verdict.NonceGaps)
+	}
+}
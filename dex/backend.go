@@ -18,9 +18,12 @@
 package dex
 
 import (
+	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"time"
 
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
 	"github.com/dexon-foundation/dexon-consensus/core/syncer"
 	"github.com/dexon-foundation/dexon/accounts"
 	"github.com/dexon-foundation/dexon/consensus"
@@ -73,7 +76,9 @@ type Dexon struct {
 	governance *DexconGovernance
 	network    *DexconNetwork
 
-	bp *blockProposer
+	bp       *blockProposer
+	sealer   *devSealer
+	recovery *Recovery
 
 	networkID     uint64
 	netRPCService *ethapi.PublicNetAPI
@@ -87,6 +92,10 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 	if err != nil {
 		return nil, err
 	}
+	if config.DevMode && config.Genesis == nil {
+		config.Genesis = core.DeveloperDexconGenesisBlock(0,
+			[]*ecdsa.PublicKey{&config.PrivateKey.PublicKey}, config.DevFaucet)
+	}
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb,
 		config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
@@ -137,11 +146,20 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 	dex.bloomIndexer.Start(dex.blockchain)
 
 	if config.Indexer.Enable {
-		dex.indexer = indexer.NewIndexerFromConfig(
+		indexerConfig := config.Indexer
+		indexerConfig.Database = chainDb
+		dex.indexer, err = indexer.NewIndexerFromConfig(
 			indexer.NewROBlockChain(dex.blockchain),
-			config.Indexer,
+			indexerConfig,
 		)
-		dex.indexer.Start()
+		if err != nil {
+			return nil, err
+		}
+		if dex.indexer != nil {
+			if err := dex.indexer.Start(); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if config.TxPool.Journal != "" {
@@ -161,7 +179,16 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 	dex.app = NewDexconApp(dex.txPool, dex.blockchain, dex.governance, chainDb, config)
 
 	// Set config fetcher so engine can fetch current system configuration from state.
-	engine.SetGovStateFetcher(dex.governance)
+	if config.DevMode {
+		if config.BlockProposerEnabled {
+			return nil, errors.New("developer mode seals blocks without the block proposer")
+		}
+		engine.SetGovStateFetcher(core.NewSoleNotaryGovStateFetcher(
+			dex.governance, &config.PrivateKey.PublicKey))
+		dex.sealer = newDevSealer(dex, config.PrivateKey, time.Duration(config.DevPeriod)*time.Second)
+	} else {
+		engine.SetGovStateFetcher(dex.governance)
+	}
 
 	dMoment := time.Unix(int64(chainConfig.DMoment), 0)
 	log.Info("Consensus DMoment", "dMoment", dMoment)
@@ -173,17 +200,30 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 
 	pm, err := NewProtocolManager(dex.chainConfig, config.SyncMode,
 		config.NetworkId, dex.eventMux, dex.txPool, dex.engine, dex.blockchain,
-		chainDb, config.Whitelist, config.BlockProposerEnabled, dex.governance, dex.app)
+		chainDb, config.Whitelist, config.BlockProposerEnabled,
+		config.ConsensusCache*1024*1024, config.ConsensusCacheRounds,
+		dex.governance, dex.app)
 	if err != nil {
 		return nil, err
 	}
+	if pm.notaryMesh, err = newNotaryMesh(config.NotaryMesh); err != nil {
+		return nil, err
+	}
+	if config.ConsensusRecordDir != "" {
+		pm.recorder, err = newMsgRecorder(config.ConsensusRecordDir, recordFileSize, recordFiles)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pm.tsigVerifiers = dexCore.NewTSigVerifierCache(dex.governance, 5)
+	pm.aggregateAgreements = config.AggregatedAgreement
 
 	dex.protocolManager = pm
 	dex.network = NewDexconNetwork(pm)
 
-	recovery := NewRecovery(chainConfig.Recovery, config.RecoveryNetworkRPC,
-		dex.governance, config.PrivateKey)
-	watchCat := syncer.NewWatchCat(recovery, dex.governance, 10*time.Second,
+	dex.recovery = NewRecovery(chainConfig.Recovery, config.RecoveryNetworkRPCs,
+		config.RecoveryQuorum, dex.governance, config.PrivateKey)
+	watchCat := syncer.NewWatchCat(dex.recovery, dex.governance, 10*time.Second,
 		time.Duration(chainConfig.Recovery.Timeout)*time.Second, log.Root())
 
 	dex.bp = NewBlockProposer(dex, watchCat, dMoment)
@@ -200,6 +240,11 @@ func (s *Dexon) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append any APIs exposed by the indexers
+	if provider, ok := s.indexer.(indexer.APIProvider); ok {
+		apis = append(apis, provider.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -225,6 +270,11 @@ func (s *Dexon) APIs() []rpc.API {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPrivateDebugAPI(s.chainConfig, s),
+		}, {
+			Namespace: "governance",
+			Version:   "1.0",
+			Service:   NewPublicGovernanceAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -252,6 +302,9 @@ func (s *Dexon) Start(srvr *p2p.Server) error {
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(srvr, maxPeers)
 
+	if s.sealer != nil {
+		s.sealer.Start()
+	}
 	if s.config.BlockProposerEnabled {
 		go func() {
 			// Since we might be in fast sync mode when started. wait for
@@ -272,6 +325,9 @@ func (s *Dexon) Start(srvr *p2p.Server) error {
 }
 
 func (s *Dexon) Stop() error {
+	if s.sealer != nil {
+		s.sealer.Stop()
+	}
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.engine.Close()
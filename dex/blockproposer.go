@@ -33,6 +33,9 @@ type blockProposer struct {
 
 	wg     sync.WaitGroup
 	stopCh chan struct{}
+
+	watchCatLock   sync.Mutex
+	watchCatStatus WatchCatStatus
 }
 
 func NewBlockProposer(dex *Dexon, watchCat *syncer.WatchCat, dMoment time.Time) *blockProposer {
@@ -130,7 +133,11 @@ func (b *blockProposer) syncConsensus() (*dexCore.Consensus, error) {
 
 	// Start the watchCat.
 	b.watchCat.Start()
-	defer b.watchCat.Stop()
+	b.setWatchCatRunning(true)
+	defer func() {
+		b.watchCat.Stop()
+		b.setWatchCatRunning(false)
+	}()
 	log.Info("Started sync watchCat")
 
 	// Feed the current block we have in local blockchain.
@@ -236,7 +243,11 @@ ListenLoop:
 			log.Info("WatchCat signaled to stop syncing")
 
 			b.dex.protocolManager.SetReceiveCoreMessage(true)
-			consensusSync.ForceSync(b.watchCat.LastPosition(), true)
+			position := b.watchCat.LastPosition()
+			b.watchCatLock.Lock()
+			b.watchCatStatus.LastRecovery = &position
+			b.watchCatLock.Unlock()
+			consensusSync.ForceSync(position, true)
 			break ListenLoop
 		}
 	}
@@ -244,3 +255,20 @@ ListenLoop:
 	con, err := consensusSync.GetSyncedConsensus()
 	return con, err
 }
+
+func (b *blockProposer) setWatchCatRunning(running bool) {
+	b.watchCatLock.Lock()
+	defer b.watchCatLock.Unlock()
+	b.watchCatStatus.Running = running
+	if running {
+		b.watchCatStatus.StartedAt = time.Now()
+	}
+}
+
+// WatchCatStatus returns the state of the watch cat.
+func (b *blockProposer) WatchCatStatus() *WatchCatStatus {
+	b.watchCatLock.Lock()
+	defer b.watchCatLock.Unlock()
+	status := b.watchCatStatus
+	return &status
+}
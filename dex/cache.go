@@ -18,11 +18,17 @@
 package dex
 
 import (
+	"container/heap"
+	"sort"
 	"sync"
 
 	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
 	coreDb "github.com/dexon-foundation/dexon-consensus/core/db"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
 )
 
 type voteKey struct {
@@ -43,51 +49,178 @@ func voteToKey(vote *coreTypes.Vote) voteKey {
 	}
 }
 
+const (
+	// Rough memory size of a cached vote and block, besides their variable
+	// length fields.
+	voteOverhead  = 256
+	blockOverhead = 512
+
+	// Number of heights below the delivered tip whose messages are kept.
+	cacheTipHeights = 1024
+)
+
+func voteSize(vote *coreTypes.Vote) int {
+	return voteOverhead + len(vote.Signature.Signature) +
+		len(vote.PartialSignature.Signature)
+}
+
+func blockSize(block *coreTypes.Block) int {
+	return blockOverhead + len(block.Payload) + len(block.Witness.Data) +
+		len(block.Randomness) + len(block.Signature.Signature) +
+		len(block.CRSSignature.Signature)
+}
+
+// cacheUsage accounts the messages cached at a position.
+type cacheUsage struct {
+	bytes  int
+	blocks map[coreCommon.Hash]struct{}
+}
+
+// cache holds the recent votes and blocks within a memory budget in bytes.
+// When the budget is exceeded, the messages of the oldest positions are
+// evicted first. Messages too far behind the delivered tip are evicted as
+// the tip moves.
 type cache struct {
 	lock                sync.RWMutex
 	blockCache          map[coreCommon.Hash]*coreTypes.Block
 	finalizedBlockCache map[coreTypes.Position]*coreTypes.Block
 	voteCache           map[coreTypes.Position]map[voteKey]*coreTypes.Vote
-	votePosition        []coreTypes.Position
+	usage               map[coreTypes.Position]*cacheUsage
+	positions           positionHeap
 	db                  coreDb.Database
-	voteSize            int
-	size                int
+	store               *cacheStore
+	used                int
+	budget              int
 }
 
-func newCache(size int, db coreDb.Database) *cache {
+func newCache(budget int, db coreDb.Database) *cache {
 	return &cache{
 		blockCache:          make(map[coreCommon.Hash]*coreTypes.Block),
 		finalizedBlockCache: make(map[coreTypes.Position]*coreTypes.Block),
 		voteCache:           make(map[coreTypes.Position]map[voteKey]*coreTypes.Vote),
+		usage:               make(map[coreTypes.Position]*cacheUsage),
 		db:                  db,
-		size:                size,
+		budget:              budget,
 	}
 }
 
-func (c *cache) addVote(vote *coreTypes.Vote) {
+// newPersistentCache creates a cache which also persists the votes and
+// finalized blocks of the most recent rounds in chainDB, within the same
+// budget in bytes.
+func newPersistentCache(budget int, db coreDb.Database, chainDB ethdb.Database, rounds uint64) *cache {
+	c := newCache(budget, db)
+	c.store = newCacheStore(chainDB, rounds, uint64(budget))
+	return c
+}
+
+// account adds delta bytes to the usage of pos.
+func (c *cache) account(pos coreTypes.Position, delta int) *cacheUsage {
+	usage, exist := c.usage[pos]
+	if !exist {
+		usage = &cacheUsage{blocks: make(map[coreCommon.Hash]struct{})}
+		c.usage[pos] = usage
+		heap.Push(&c.positions, pos)
+	}
+	usage.bytes += delta
+	c.used += delta
+	return usage
+}
+
+// evictPosition drops every message cached at pos.
+func (c *cache) evictPosition(pos coreTypes.Position) {
+	usage, exist := c.usage[pos]
+	if !exist {
+		return
+	}
+	evicted := len(c.voteCache[pos]) + len(usage.blocks)
+	for hash := range usage.blocks {
+		delete(c.blockCache, hash)
+	}
+	delete(c.voteCache, pos)
+	delete(c.finalizedBlockCache, pos)
+	delete(c.usage, pos)
+	c.used -= usage.bytes
+	cacheEvictionMeter.Mark(int64(evicted))
+	cacheEvictedBytesMeter.Mark(int64(usage.bytes))
+}
+
+// evict drops the messages of the oldest positions until the cache fits in
+// its budget.
+func (c *cache) evict() {
+	for c.used > c.budget && c.positions.Len() > 0 {
+		c.evictPosition(heap.Pop(&c.positions).(coreTypes.Position))
+	}
+	cacheSizeGauge.Update(int64(c.used))
+}
+
+// setTip evicts the messages of positions too far behind the delivered tip:
+// positions of rounds before the previous one, or more than cacheTipHeights
+// below the tip.
+func (c *cache) setTip(tip coreTypes.Position) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
-	if c.voteSize >= c.size {
-		pos := c.votePosition[0]
-		c.voteSize -= len(c.voteCache[pos])
-		delete(c.voteCache, pos)
-		c.votePosition = c.votePosition[1:]
+	for c.positions.Len() > 0 {
+		pos := c.positions[0]
+		if pos.Round+1 >= tip.Round && pos.Height+cacheTipHeights >= tip.Height {
+			break
+		}
+		c.evictPosition(heap.Pop(&c.positions).(coreTypes.Position))
+	}
+	cacheSizeGauge.Update(int64(c.used))
+}
+
+func (c *cache) addVote(vote *coreTypes.Vote) {
+	// The store is read and written out of the lock, not to hold the other
+	// message handlers on the disk.
+	var stored []*coreTypes.Vote
+	if c.store != nil {
+		c.lock.RLock()
+		_, cached := c.voteCache[vote.Position]
+		c.lock.RUnlock()
+		if !cached {
+			stored = c.store.votes(vote.Position)
+		}
+	}
+	c.lock.Lock()
+	added := c.addVoteNoLock(vote, stored)
+	c.lock.Unlock()
+	if added && c.store != nil {
+		c.store.putVote(vote)
 	}
+}
+
+// addVoteNoLock caches vote and reports if it wasn't cached yet. The votes
+// stored at its position are merged if the position isn't cached yet, not to
+// persist them again.
+func (c *cache) addVoteNoLock(vote *coreTypes.Vote, stored []*coreTypes.Vote) bool {
 	if _, exist := c.voteCache[vote.Position]; !exist {
-		c.votePosition = append(c.votePosition, vote.Position)
 		c.voteCache[vote.Position] = make(map[voteKey]*coreTypes.Vote)
+		for _, v := range stored {
+			c.voteCache[vote.Position][voteToKey(v)] = v
+			c.account(v.Position, voteSize(v))
+		}
 	}
 	key := voteToKey(vote)
 	if _, exist := c.voteCache[vote.Position][key]; exist {
-		return
+		return false
 	}
 	c.voteCache[vote.Position][key] = vote
-	c.voteSize++
+	c.account(vote.Position, voteSize(vote))
+	c.evict()
+	return true
 }
 
 func (c *cache) votes(pos coreTypes.Position) []*coreTypes.Vote {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
+	if _, exist := c.voteCache[pos]; !exist {
+		cacheVoteMissMeter.Mark(1)
+		if c.store != nil {
+			return c.store.votes(pos)
+		}
+		return []*coreTypes.Vote{}
+	}
+	cacheVoteHitMeter.Mark(1)
 	votes := make([]*coreTypes.Vote, 0, len(c.voteCache[pos]))
 	for _, vote := range c.voteCache[pos] {
 		votes = append(votes, vote)
@@ -96,15 +229,19 @@ func (c *cache) votes(pos coreTypes.Position) []*coreTypes.Vote {
 }
 
 func (c *cache) addBlocks(blocks []*coreTypes.Block) {
+	var finalized []*coreTypes.Block
 	c.lock.Lock()
-	defer c.lock.Unlock()
 	for _, b := range blocks {
 		if b.IsFinalized() {
-			c.addFinalizedBlockNoLock(b)
+			if c.addFinalizedBlockNoLock(b) {
+				finalized = append(finalized, b)
+			}
 		} else {
 			c.addBlockNoLock(b)
 		}
 	}
+	c.lock.Unlock()
+	c.persistFinalizedBlocks(finalized...)
 }
 
 func (c *cache) addBlock(block *coreTypes.Block) {
@@ -113,6 +250,17 @@ func (c *cache) addBlock(block *coreTypes.Block) {
 	c.addBlockNoLock(block)
 }
 
+// putBlockNoLock caches block by its hash, replacing the cached one if any.
+func (c *cache) putBlockNoLock(block *coreTypes.Block) {
+	if old, exist := c.blockCache[block.Hash]; exist {
+		usage := c.account(old.Position, -blockSize(old))
+		delete(usage.blocks, old.Hash)
+	}
+	c.blockCache[block.Hash] = block
+	usage := c.account(block.Position, blockSize(block))
+	usage.blocks[block.Hash] = struct{}{}
+}
+
 func (c *cache) addBlockNoLock(block *coreTypes.Block) {
 	// Avoid polluting cache by non-finalized blocks when we've received some
 	// finalized block from the same position.
@@ -120,40 +268,39 @@ func (c *cache) addBlockNoLock(block *coreTypes.Block) {
 		return
 	}
 	block = block.Clone()
-	if len(c.blockCache) >= c.size {
-		// Randomly delete one entry.
-		for k := range c.blockCache {
-			delete(c.blockCache, k)
-			break
-		}
-	}
-	c.blockCache[block.Hash] = block
+	c.putBlockNoLock(block)
+	c.evict()
 }
 
 func (c *cache) addFinalizedBlock(block *coreTypes.Block) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
-	c.addFinalizedBlockNoLock(block)
+	added := c.addFinalizedBlockNoLock(block)
+	c.lock.Unlock()
+	if added {
+		c.persistFinalizedBlocks(block)
+	}
 }
 
-func (c *cache) addFinalizedBlockNoLock(block *coreTypes.Block) {
+// addFinalizedBlockNoLock caches block and reports if no finalized block was
+// cached at its position yet.
+func (c *cache) addFinalizedBlockNoLock(block *coreTypes.Block) bool {
+	_, exist := c.finalizedBlockCache[block.Position]
 	block = block.Clone()
-	if len(c.blockCache) >= c.size {
-		// Randomly delete one entry.
-		for k := range c.blockCache {
-			delete(c.blockCache, k)
-			break
-		}
+	c.putBlockNoLock(block)
+	c.finalizedBlockCache[block.Position] = block
+	c.evict()
+	return !exist
+}
+
+// persistFinalizedBlocks persists blocks in the store, if any. It is called
+// out of the lock, not to hold the other message handlers on the disk.
+func (c *cache) persistFinalizedBlocks(blocks ...*coreTypes.Block) {
+	if c.store == nil {
+		return
 	}
-	if len(c.finalizedBlockCache) >= c.size {
-		// Randomly delete one entry.
-		for k := range c.finalizedBlockCache {
-			delete(c.finalizedBlockCache, k)
-			break
-		}
+	for _, block := range blocks {
+		c.store.putFinalizedBlock(block)
 	}
-	c.blockCache[block.Hash] = block
-	c.finalizedBlockCache[block.Position] = block
 }
 
 func (c *cache) blocks(hashes coreCommon.Hashes, includeDB bool) []*coreTypes.Block {
@@ -162,8 +309,12 @@ func (c *cache) blocks(hashes coreCommon.Hashes, includeDB bool) []*coreTypes.Bl
 	cacheBlocks := make([]*coreTypes.Block, 0, len(hashes))
 	for _, hash := range hashes {
 		if block, exist := c.blockCache[hash]; exist {
+			cacheBlockHitMeter.Mark(1)
 			cacheBlocks = append(cacheBlocks, block)
-		} else if includeDB {
+			continue
+		}
+		cacheBlockMissMeter.Mark(1)
+		if includeDB {
 			block, err := c.db.GetBlock(hash)
 			if err != nil {
 				continue
@@ -178,8 +329,199 @@ func (c *cache) finalizedBlock(pos coreTypes.Position) *coreTypes.Block {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	if block, exist := c.finalizedBlockCache[pos]; exist {
+		cacheBlockHitMeter.Mark(1)
 		return block
 	}
-	// TODO(jimmy): get finalized block from db
+	cacheBlockMissMeter.Mark(1)
+	if c.store != nil {
+		return c.store.finalizedBlock(pos)
+	}
 	return nil
 }
+
+// positionHeap is a min-heap of positions, oldest first.
+type positionHeap []coreTypes.Position
+
+func (h positionHeap) Len() int           { return len(h) }
+func (h positionHeap) Less(i, j int) bool { return h[i].Older(h[j]) }
+func (h positionHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *positionHeap) Push(x interface{}) {
+	*h = append(*h, x.(coreTypes.Position))
+}
+
+func (h *positionHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+// cacheStore persists the votes and finalized blocks of the most recent
+// rounds by position, so that a restarted node can still serve them to its
+// peers. Every vote is stored under its own key. Older rounds are pruned as
+// newer ones are stored, or once the stored messages exceed the budget.
+type cacheStore struct {
+	lock    sync.Mutex
+	db      ethdb.Database
+	rounds  uint64
+	budget  uint64
+	latest  uint64
+	used    uint64
+	heights map[uint64]map[uint64]struct{}
+	sizes   map[uint64]uint64
+	counts  map[coreTypes.Position]uint64
+}
+
+func newCacheStore(db ethdb.Database, rounds uint64, budget uint64) *cacheStore {
+	s := &cacheStore{
+		db:      db,
+		rounds:  rounds,
+		budget:  budget,
+		heights: make(map[uint64]map[uint64]struct{}),
+		sizes:   make(map[uint64]uint64),
+		counts:  make(map[coreTypes.Position]uint64),
+	}
+	for _, round := range rawdb.ReadCoreCacheRounds(db) {
+		heights := make(map[uint64]struct{})
+		for _, height := range rawdb.ReadCoreCacheHeights(db, round) {
+			heights[height] = struct{}{}
+		}
+		s.heights[round] = heights
+		s.sizes[round] = rawdb.ReadCoreCacheSize(db, round)
+		s.used += s.sizes[round]
+		if round > s.latest {
+			s.latest = round
+		}
+	}
+	s.prune()
+	return s
+}
+
+// track records size bytes to be stored at a position through batch, and
+// reports if the position belongs to one of the rounds kept and fits in the
+// budget. Older rounds are pruned to make room.
+func (s *cacheStore) track(batch ethdb.Batch, pos coreTypes.Position, size uint64) bool {
+	if pos.Round+s.rounds <= s.latest {
+		return false
+	}
+	if pos.Round > s.latest {
+		s.latest = pos.Round
+		s.prune()
+	}
+	for s.used+size > s.budget {
+		rounds := s.sortedRounds()
+		if len(rounds) == 0 || rounds[0] >= pos.Round {
+			return false
+		}
+		s.pruneRound(rounds[0])
+		rawdb.WriteCoreCacheRounds(batch, s.sortedRounds())
+	}
+	heights, exist := s.heights[pos.Round]
+	if !exist {
+		heights = make(map[uint64]struct{})
+		s.heights[pos.Round] = heights
+		rawdb.WriteCoreCacheRounds(batch, s.sortedRounds())
+	}
+	if _, exist := heights[pos.Height]; !exist {
+		heights[pos.Height] = struct{}{}
+		list := make([]uint64, 0, len(heights))
+		for height := range heights {
+			list = append(list, height)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+		rawdb.WriteCoreCacheHeights(batch, pos.Round, list)
+	}
+	s.sizes[pos.Round] += size
+	s.used += size
+	rawdb.WriteCoreCacheSize(batch, pos.Round, s.sizes[pos.Round])
+	return true
+}
+
+// prune deletes the rounds older than the kept ones.
+func (s *cacheStore) prune() {
+	pruned := false
+	for round := range s.heights {
+		if round+s.rounds > s.latest {
+			continue
+		}
+		s.pruneRound(round)
+		pruned = true
+	}
+	if pruned {
+		rawdb.WriteCoreCacheRounds(s.db, s.sortedRounds())
+	}
+}
+
+// pruneRound deletes the votes and finalized blocks stored in a round.
+func (s *cacheStore) pruneRound(round uint64) {
+	for height := range s.heights[round] {
+		pos := coreTypes.Position{Round: round, Height: height}
+		rawdb.DeleteCoreCacheVotes(s.db, pos, s.voteCount(pos))
+		rawdb.DeleteCoreCacheFinalizedBlock(s.db, pos)
+		delete(s.counts, pos)
+	}
+	rawdb.DeleteCoreCacheHeights(s.db, round)
+	rawdb.DeleteCoreCacheSize(s.db, round)
+	s.used -= s.sizes[round]
+	delete(s.heights, round)
+	delete(s.sizes, round)
+}
+
+func (s *cacheStore) sortedRounds() []uint64 {
+	rounds := make([]uint64, 0, len(s.heights))
+	for round := range s.heights {
+		rounds = append(rounds, round)
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i] < rounds[j] })
+	return rounds
+}
+
+// voteCount returns the number of votes stored at a position.
+func (s *cacheStore) voteCount(pos coreTypes.Position) uint64 {
+	count, exist := s.counts[pos]
+	if !exist {
+		count = rawdb.ReadCoreCacheVoteCount(s.db, pos)
+		s.counts[pos] = count
+	}
+	return count
+}
+
+func (s *cacheStore) write(batch ethdb.Batch) {
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to store consensus cache", "err", err)
+	}
+}
+
+func (s *cacheStore) putVote(vote *coreTypes.Vote) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	batch := s.db.NewBatch()
+	if !s.track(batch, vote.Position, uint64(voteSize(vote))) {
+		return
+	}
+	count := s.voteCount(vote.Position)
+	rawdb.WriteCoreCacheVote(batch, count, vote)
+	s.counts[vote.Position] = count + 1
+	s.write(batch)
+}
+
+func (s *cacheStore) votes(pos coreTypes.Position) []*coreTypes.Vote {
+	return rawdb.ReadCoreCacheVotes(s.db, pos)
+}
+
+func (s *cacheStore) putFinalizedBlock(block *coreTypes.Block) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	batch := s.db.NewBatch()
+	if !s.track(batch, block.Position, uint64(blockSize(block))) {
+		return
+	}
+	rawdb.WriteCoreCacheFinalizedBlock(batch, block)
+	s.write(batch)
+}
+
+func (s *cacheStore) finalizedBlock(pos coreTypes.Position) *coreTypes.Block {
+	return rawdb.ReadCoreCacheFinalizedBlock(s.db, pos)
+}
@@ -27,6 +27,10 @@ import (
 	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
 	coreDb "github.com/dexon-foundation/dexon-consensus/core/db"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	dexDB "github.com/dexon-foundation/dexon/dex/db"
+	"github.com/dexon-foundation/dexon/ethdb"
 )
 
 type byHash []*coreTypes.Vote
@@ -48,7 +52,7 @@ func TestCacheVote(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
-	cache := newCache(3, db)
+	cache := newCache(3*voteOverhead, db)
 	pos0 := coreTypes.Position{
 		Height: uint64(0),
 	}
@@ -137,17 +141,29 @@ func TestCacheBlock(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
-	cache := newCache(3, db)
+	cache := newCache(3*blockOverhead, db)
 	block1 := &coreTypes.Block{
+		Position: coreTypes.Position{
+			Height: 1,
+		},
 		Hash: coreCommon.NewRandomHash(),
 	}
 	block2 := &coreTypes.Block{
+		Position: coreTypes.Position{
+			Height: 2,
+		},
 		Hash: coreCommon.NewRandomHash(),
 	}
 	block3 := &coreTypes.Block{
+		Position: coreTypes.Position{
+			Height: 3,
+		},
 		Hash: coreCommon.NewRandomHash(),
 	}
 	block4 := &coreTypes.Block{
+		Position: coreTypes.Position{
+			Height: 4,
+		},
 		Hash: coreCommon.NewRandomHash(),
 	}
 	cache.addBlock(block1)
@@ -215,7 +231,7 @@ func TestCacheFinalizedBlock(t *testing.T) {
 	if err != nil {
 		panic(err)
 	}
-	cache := newCache(3, db)
+	cache := newCache(3*(blockOverhead+32), db)
 	block1 := &coreTypes.Block{
 		Position: coreTypes.Position{
 			Height: 1,
@@ -317,6 +333,189 @@ func TestCacheFinalizedBlock(t *testing.T) {
 	}
 }
 
+func TestCachePersistent(t *testing.T) {
+	chainDB := ethdb.NewMemDatabase()
+	db := dexDB.NewDatabase(chainDB)
+	cache := newPersistentCache(1<<20, db, chainDB, 2)
+
+	newVote := func(pos coreTypes.Position) *coreTypes.Vote {
+		return &coreTypes.Vote{
+			VoteHeader: coreTypes.VoteHeader{
+				BlockHash: coreCommon.NewRandomHash(),
+				Position:  pos,
+			},
+		}
+	}
+	newFinalizedBlock := func(pos coreTypes.Position) *coreTypes.Block {
+		return &coreTypes.Block{
+			Position:   pos,
+			Hash:       coreCommon.NewRandomHash(),
+			Randomness: randomBytes(),
+		}
+	}
+	pos0 := coreTypes.Position{Round: 0, Height: 1}
+	pos1 := coreTypes.Position{Round: 1, Height: 2}
+	vote0, vote1 := newVote(pos0), newVote(pos1)
+	block0, block1 := newFinalizedBlock(pos0), newFinalizedBlock(pos1)
+	cache.addVote(vote0)
+	cache.addVote(vote1)
+	cache.addFinalizedBlock(block0)
+	cache.addFinalizedBlock(block1)
+
+	// A finalized block added again is not stored again.
+	used := cache.store.used
+	cache.addFinalizedBlock(block1)
+	if cache.store.used != used {
+		t.Errorf("stored bytes mismatch: have %d, want %d", cache.store.used, used)
+	}
+
+	// A restarted node still serves the persisted votes and blocks.
+	cache = newPersistentCache(1<<20, db, chainDB, 2)
+	if votes := cache.votes(pos0); len(votes) != 1 || votes[0].BlockHash != vote0.BlockHash {
+		t.Errorf("persisted votes mismatch: %v", votes)
+	}
+	if block := cache.finalizedBlock(pos1); block == nil || block.Hash != block1.Hash {
+		t.Errorf("persisted finalized block mismatch: %v", block)
+	}
+
+	// Votes received after the restart are added to the persisted ones.
+	cache.addVote(newVote(pos1))
+	if votes := newPersistentCache(1<<20, db, chainDB, 2).votes(pos1); len(votes) != 2 {
+		t.Errorf("persisted votes mismatch: have %d, want 2", len(votes))
+	}
+
+	// Storing round 2 prunes round 0.
+	pos2 := coreTypes.Position{Round: 2, Height: 3}
+	cache.addFinalizedBlock(newFinalizedBlock(pos2))
+	cache = newPersistentCache(1<<20, db, chainDB, 2)
+	if votes := cache.votes(pos0); len(votes) != 0 {
+		t.Errorf("votes of pruned round returned: %v", votes)
+	}
+	if block := cache.finalizedBlock(pos0); block != nil {
+		t.Errorf("finalized block of pruned round returned: %v", block)
+	}
+	if block := cache.finalizedBlock(pos1); block == nil {
+		t.Errorf("finalized block of kept round not returned")
+	}
+	cache.addVote(newVote(pos0))
+	if votes := newPersistentCache(1<<20, db, chainDB, 2).votes(pos0); len(votes) != 0 {
+		t.Errorf("votes of old round persisted: %v", votes)
+	}
+
+	// Every vote is stored under its own key.
+	if count := rawdb.ReadCoreCacheVoteCount(chainDB, pos1); count != 2 {
+		t.Errorf("stored vote count mismatch: have %d, want 2", count)
+	}
+}
+
+func TestCachePersistentBudget(t *testing.T) {
+	chainDB := ethdb.NewMemDatabase()
+	db := dexDB.NewDatabase(chainDB)
+
+	newVote := func(pos coreTypes.Position) *coreTypes.Vote {
+		return &coreTypes.Vote{
+			VoteHeader: coreTypes.VoteHeader{
+				BlockHash: coreCommon.NewRandomHash(),
+				Position:  pos,
+			},
+		}
+	}
+	// Room for the votes of a single position.
+	budget := 4 * voteSize(newVote(coreTypes.Position{}))
+	cache := newPersistentCache(budget, db, chainDB, 4)
+
+	pos0 := coreTypes.Position{Round: 0, Height: 1}
+	pos1 := coreTypes.Position{Round: 1, Height: 2}
+	for i := 0; i < 4; i++ {
+		cache.addVote(newVote(pos0))
+	}
+	// Votes beyond the budget in the newest round are not persisted.
+	cache.addVote(newVote(pos0))
+	if votes := cache.store.votes(pos0); len(votes) != 4 {
+		t.Errorf("persisted votes mismatch: have %d, want 4", len(votes))
+	}
+
+	// A newer round makes room by pruning the older ones.
+	cache.addVote(newVote(pos1))
+	cache = newPersistentCache(budget, db, chainDB, 4)
+	if votes := cache.votes(pos0); len(votes) != 0 {
+		t.Errorf("votes of pruned round returned: %v", votes)
+	}
+	if votes := cache.votes(pos1); len(votes) != 1 {
+		t.Errorf("persisted votes mismatch: have %d, want 1", len(votes))
+	}
+	if cache.store.used != uint64(voteSize(newVote(pos1))) {
+		t.Errorf("stored bytes mismatch: have %d, want %d", cache.store.used, voteSize(newVote(pos1)))
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	db, err := coreDb.NewMemBackedDB()
+	if err != nil {
+		panic(err)
+	}
+	newBlock := func(round, height uint64, payload int) *coreTypes.Block {
+		return &coreTypes.Block{
+			Position: coreTypes.Position{Round: round, Height: height},
+			Hash:     coreCommon.NewRandomHash(),
+			Payload:  make([]byte, payload),
+		}
+	}
+	newVote := func(round, height uint64) *coreTypes.Vote {
+		return &coreTypes.Vote{
+			VoteHeader: coreTypes.VoteHeader{
+				BlockHash: coreCommon.NewRandomHash(),
+				Position:  coreTypes.Position{Round: round, Height: height},
+			},
+		}
+	}
+	cache := newCache(2*blockOverhead+1000+voteOverhead, db)
+
+	// Blocks and votes share the budget, and the oldest position goes first.
+	block1 := newBlock(0, 1, 1000)
+	block2 := newBlock(0, 2, 0)
+	cache.addBlock(block1)
+	cache.addVote(newVote(0, 1))
+	cache.addBlock(block2)
+	if cache.used != blockSize(block1)+blockSize(block2)+voteOverhead {
+		t.Errorf("used bytes mismatch: have %d", cache.used)
+	}
+	cache.addVote(newVote(0, 3))
+	if blocks := cache.blocks(coreCommon.Hashes{block1.Hash}, false); len(blocks) != 0 {
+		t.Errorf("block of oldest position not evicted")
+	}
+	if votes := cache.votes(block1.Position); len(votes) != 0 {
+		t.Errorf("votes of oldest position not evicted: %v", votes)
+	}
+	if blocks := cache.blocks(coreCommon.Hashes{block2.Hash}, false); len(blocks) != 1 {
+		t.Errorf("block of newer position evicted")
+	}
+	if cache.used != blockSize(block2)+voteOverhead {
+		t.Errorf("used bytes mismatch: have %d", cache.used)
+	}
+
+	// Messages far behind the delivered tip are evicted.
+	block3 := newBlock(1, 4, 0)
+	cache.addFinalizedBlock(block3)
+	cache.setTip(coreTypes.Position{Round: 2, Height: 3 + cacheTipHeights})
+	if blocks := cache.blocks(coreCommon.Hashes{block2.Hash}, false); len(blocks) != 0 {
+		t.Errorf("block of old round not evicted")
+	}
+	if votes := cache.votes(coreTypes.Position{Round: 0, Height: 3}); len(votes) != 0 {
+		t.Errorf("votes of old round not evicted: %v", votes)
+	}
+	if block := cache.finalizedBlock(block3.Position); block == nil {
+		t.Errorf("finalized block within the tip window evicted")
+	}
+	cache.setTip(coreTypes.Position{Round: 2, Height: 5 + cacheTipHeights})
+	if block := cache.finalizedBlock(block3.Position); block != nil {
+		t.Errorf("finalized block behind the tip window not evicted")
+	}
+	if cache.used != 0 {
+		t.Errorf("used bytes mismatch: have %d, want 0", cache.used)
+	}
+}
+
 func randomBytes() []byte {
 	bytes := make([]byte, 32)
 	for i := range bytes {
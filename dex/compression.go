@@ -0,0 +1,115 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+// Since dex65, the payloads of the messages below are prefixed by a flag
+// telling whether the rest is snappy compressed. Payloads are compressed only
+// if they reach compressionThreshold and compression makes them smaller.
+const (
+	payloadRaw    = 0x00
+	payloadSnappy = 0x01
+
+	compressionThreshold = 1024
+)
+
+// compressedMsgCodes are the codes of the messages whose payload may be
+// compressed.
+var compressedMsgCodes = map[uint64]struct{}{
+	BlockBodiesMsg: {},
+	CoreBlockMsg:   {},
+	AgreementMsg:   {},
+	GovStateMsg:    {},
+}
+
+// compressingMsgReadWriter is a wrapper around a p2p.MsgReadWriter,
+// compressing and decompressing the payloads of the messages exchanged with a
+// peer on protocol versions supporting it.
+type compressingMsgReadWriter struct {
+	p2p.MsgReadWriter
+}
+
+func (rw *compressingMsgReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	if _, ok := compressedMsgCodes[msg.Code]; !ok {
+		return msg, nil
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return msg, errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	data, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	if len(data) == 0 {
+		return msg, errResp(ErrDecode, "msg %v: missing payload flag", msg)
+	}
+	payload := data[1:]
+	switch data[0] {
+	case payloadRaw:
+	case payloadSnappy:
+		size, err := snappy.DecodedLen(payload)
+		if err != nil {
+			return msg, errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if size > ProtocolMaxMsgSize {
+			return msg, errResp(ErrMsgTooLarge, "%v > %v", size, ProtocolMaxMsgSize)
+		}
+		if payload, err = snappy.Decode(nil, payload); err != nil {
+			return msg, errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		compressedInTrafficMeter.Mark(int64(msg.Size))
+		decompressedInTrafficMeter.Mark(int64(len(payload)))
+	default:
+		return msg, errResp(ErrDecode, "msg %v: unknown payload flag %#x", msg, data[0])
+	}
+	msg.Size, msg.Payload = uint32(len(payload)), bytes.NewReader(payload)
+	return msg, nil
+}
+
+func (rw *compressingMsgReadWriter) WriteMsg(msg p2p.Msg) error {
+	if _, ok := compressedMsgCodes[msg.Code]; !ok {
+		return rw.MsgReadWriter.WriteMsg(msg)
+	}
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	data := append([]byte{payloadRaw}, payload...)
+	if len(payload) >= compressionThreshold {
+		compressed := snappy.Encode(nil, payload)
+		if len(compressed) < len(payload) {
+			data = append([]byte{payloadSnappy}, compressed...)
+			compressedOutTrafficMeter.Mark(int64(len(data)))
+			decompressedOutTrafficMeter.Mark(int64(len(payload)))
+		}
+	}
+	msg.Size, msg.Payload = uint32(len(data)), bytes.NewReader(data)
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
@@ -0,0 +1,137 @@
+package dex
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/dex/downloader"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+func TestCompressingMsgReadWriter(t *testing.T) {
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+	rw := &compressingMsgReadWriter{MsgReadWriter: local}
+
+	large := bytes.Repeat([]byte{1, 2, 3, 4}, compressionThreshold)
+	small := []byte{1, 2, 3, 4}
+	tests := []struct {
+		code uint64
+		data []byte
+		flag int // expected payload flag on the wire, -1 if none
+	}{
+		{CoreBlockMsg, large, payloadSnappy},
+		{AgreementMsg, small, payloadRaw},
+		{VoteMsg, large, -1},
+	}
+	for i, tt := range tests {
+		i, tt := i, tt
+		go func() {
+			if err := p2p.Send(rw, tt.code, tt.data); err != nil {
+				t.Errorf("test %d: send error: %v", i, err)
+			}
+		}()
+		msg, err := remote.ReadMsg()
+		if err != nil {
+			t.Fatalf("test %d: read error: %v", i, err)
+		}
+		wire, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			t.Fatalf("test %d: read error: %v", i, err)
+		}
+		encoded, _ := rlp.EncodeToBytes(tt.data)
+		switch {
+		case tt.flag < 0:
+			if !bytes.Equal(wire, encoded) {
+				t.Errorf("test %d: payload altered", i)
+			}
+		case wire[0] != byte(tt.flag):
+			t.Errorf("test %d: payload flag mismatch: got %#x, want %#x", i, wire[0], tt.flag)
+		case tt.flag == payloadSnappy && len(wire) >= len(encoded):
+			t.Errorf("test %d: payload not compressed: %d bytes", i, len(wire))
+		}
+
+		// Write the payload back, the reader must restore it.
+		go func() {
+			if err := remote.WriteMsg(p2p.Msg{Code: tt.code, Size: uint32(len(wire)), Payload: bytes.NewReader(wire)}); err != nil {
+				t.Errorf("test %d: write error: %v", i, err)
+			}
+		}()
+		msg, err = rw.ReadMsg()
+		if err != nil {
+			t.Fatalf("test %d: read error: %v", i, err)
+		}
+		if msg.Size != uint32(len(encoded)) {
+			t.Errorf("test %d: size mismatch: got %d, want %d", i, msg.Size, len(encoded))
+		}
+		var data []byte
+		if err := msg.Decode(&data); err != nil {
+			t.Fatalf("test %d: decode error: %v", i, err)
+		}
+		if !bytes.Equal(data, tt.data) {
+			t.Errorf("test %d: data mismatch", i)
+		}
+	}
+
+	// Payloads without a valid flag are rejected.
+	go p2p.Send(remote, GovStateMsg, []byte{})
+	if _, err := rw.ReadMsg(); err == nil {
+		t.Errorf("invalid payload flag accepted")
+	}
+}
+
+func TestRecvCompressedCoreBlocks(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.SetReceiveCoreMessage(true)
+
+	p, _ := newTestPeer("peer", dex65, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	block := coreTypes.Block{
+		ProposerID: coreTypes.NodeID{coreCommon.Hash{1, 2, 3}},
+		ParentHash: coreCommon.Hash{1, 1, 1, 1, 1},
+		Hash:       coreCommon.Hash{2, 2, 2, 2, 2},
+		Position:   coreTypes.Position{Round: 12, Height: 13},
+		Timestamp:  time.Now().UTC(),
+		Payload:    bytes.Repeat([]byte{3}, 4*compressionThreshold),
+		Witness: coreTypes.Witness{
+			Height: 13,
+			Data:   []byte{4, 4, 4, 4, 4},
+		},
+		Randomness: []byte{5, 5, 5, 5, 5},
+		Signature: coreCrypto.Signature{
+			Type:      "signature",
+			Signature: []byte("signature"),
+		},
+		CRSSignature: coreCrypto.Signature{
+			Type:      "crs-signature",
+			Signature: []byte("crs-signature"),
+		},
+	}
+	if err := newTestCoreSigner().SignBlock(&block); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
+	rw := &compressingMsgReadWriter{MsgReadWriter: p.app}
+	if err := p2p.Send(rw, CoreBlockMsg, []*coreTypes.Block{&block}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+
+	select {
+	case msg := <-pm.ReceiveChan():
+		rb := msg.Payload.(*coreTypes.Block)
+		if !reflect.DeepEqual(rb, &block) {
+			t.Errorf("block mismatch")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("no core block received within 1 seconds")
+	}
+}
@@ -39,6 +39,7 @@ var DefaultConfig = Config{
 	NetworkId:      237,
 	LightPeers:     100,
 	DatabaseCache:  768,
+	ConsensusCache: 64,
 	TrieCleanCache: 256,
 	TrieDirtyCache: 256,
 	TrieTimeout:    60 * time.Minute,
@@ -98,6 +99,17 @@ type Config struct {
 	TrieDirtyCache     int
 	TrieTimeout        time.Duration
 
+	// Megabytes of memory allowed to cache recent consensus messages
+	ConsensusCache int
+
+	// Number of recent rounds of consensus messages persisted within the
+	// ConsensusCache budget (0 = disabled)
+	ConsensusCacheRounds uint64
+
+	// Directory to record the consensus messages exchanged with peers to
+	// (empty = disabled)
+	ConsensusRecordDir string `toml:",omitempty"`
+
 	// For calculate gas limit
 	DefaultGasPrice *big.Int
 
@@ -109,6 +121,21 @@ type Config struct {
 
 	// BlockProposer options
 	BlockProposerEnabled bool
+	PayloadPolicy        PayloadPolicyConfig
+
+	// Developer mode, sealing blocks on the local node as the sole notary
+	// every DevPeriod seconds (0 = seal only if transactions pending). Without
+	// a genesis, one staking the local node and funding DevFaucet is used.
+	DevMode   bool
+	DevPeriod uint64
+	DevFaucet common.Address
+
+	// Relay agreement results with their threshold signature instead of the
+	// full vote list
+	AggregatedAgreement bool
+
+	// Preferred links to known notary nodes and per-label connection limits
+	NotaryMesh NotaryMeshConfig
 
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
@@ -131,6 +158,12 @@ type Config struct {
 	// Indexer config
 	Indexer indexer.Config
 
-	// Recovery network RPC
-	RecoveryNetworkRPC string
+	// Recovery network RPC endpoints, and how many of them must agree on
+	// the votes (0 = majority)
+	RecoveryNetworkRPCs []string
+	RecoveryQuorum      int
+
+	// Deprecated: use RecoveryNetworkRPCs. The endpoint of config files
+	// written before is folded into RecoveryNetworkRPCs when they are loaded.
+	RecoveryNetworkRPC string `toml:",omitempty"`
 }
@@ -0,0 +1,141 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"crypto/ecdsa"
+	"sync"
+	"time"
+
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/log"
+)
+
+// devSealer seals blocks on the local node as the sole notary of a developer
+// chain. Blocks go through the same path as the blocks delivered by the
+// consensus core, so the payload is checked by the app and the blocks are
+// finalized by the dexcon engine, paying rewards and tracking round heights.
+type devSealer struct {
+	dex    *Dexon
+	period time.Duration
+	id     coreTypes.NodeID
+
+	wg     sync.WaitGroup
+	stopCh chan struct{}
+}
+
+func newDevSealer(dex *Dexon, key *ecdsa.PrivateKey, period time.Duration) *devSealer {
+	return &devSealer{
+		dex:    dex,
+		period: period,
+		id:     coreTypes.NewNodeID(coreEcdsa.NewPublicKeyFromECDSA(&key.PublicKey)),
+	}
+}
+
+// Start starts sealing a block for every batch of new transactions, or every
+// period if one is set.
+func (s *devSealer) Start() {
+	log.Info("Started developer block sealer", "period", s.period)
+
+	s.stopCh = make(chan struct{})
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop stops sealing blocks.
+func (s *devSealer) Stop() {
+	if s.stopCh == nil {
+		return
+	}
+	close(s.stopCh)
+	s.wg.Wait()
+	log.Info("Developer block sealer stopped")
+}
+
+func (s *devSealer) loop() {
+	defer s.wg.Done()
+
+	txsCh := make(chan core.NewTxsEvent, txChanSize)
+	sub := s.dex.txPool.SubscribeNewTxsEvent(txsCh)
+	defer sub.Unsubscribe()
+
+	var tick <-chan time.Time
+	if s.period > 0 {
+		ticker := time.NewTicker(s.period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-txsCh:
+			if s.period == 0 {
+				s.seal()
+			}
+		case <-tick:
+			s.seal()
+		case <-sub.Err():
+			return
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// seal proposes, confirms and delivers a block on top of the current one.
+func (s *devSealer) seal() {
+	current := s.dex.blockchain.CurrentBlock()
+	position := coreTypes.Position{
+		Round:  s.dex.blockchain.NextBlockRound(),
+		Height: current.NumberU64() + 1,
+	}
+	payload, err := s.dex.app.PreparePayload(position)
+	if err != nil {
+		log.Error("Failed to prepare payload", "position", position, "err", err)
+		return
+	}
+	witness, err := s.dex.app.PrepareWitness(current.NumberU64())
+	if err != nil {
+		log.Error("Failed to prepare witness", "position", position, "err", err)
+		return
+	}
+	block := &coreTypes.Block{
+		ProposerID: s.id,
+		Position:   position,
+		Timestamp:  time.Now().UTC(),
+		Payload:    payload,
+		Witness:    witness,
+	}
+	if block.Hash, err = coreUtils.HashBlock(block); err != nil {
+		log.Error("Failed to hash block", "position", position, "err", err)
+		return
+	}
+	if status := s.dex.app.VerifyBlock(block); status != coreTypes.VerifyOK {
+		log.Error("Sealed block failed verification", "position", position, "status", status)
+		return
+	}
+	// The randomness can't be signed by a DKG set, derive it from the parent
+	randomness := crypto.Keccak256(current.Randomness(), block.Hash[:])
+
+	s.dex.app.BlockConfirmed(*block)
+	s.dex.app.BlockDelivered(block.Hash, block.Position, randomness)
+}
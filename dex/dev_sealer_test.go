@@ -0,0 +1,93 @@
+package dex
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+)
+
+func newDevDexon(nodeKey *ecdsa.PrivateKey, faucet common.Address, roundLength uint64) (*Dexon, error) {
+	db := ethdb.NewMemDatabase()
+
+	genesis := core.DeveloperDexconGenesisBlock(0, []*ecdsa.PublicKey{&nodeKey.PublicKey}, faucet)
+	genesis.Config.Dexcon.RoundLength = roundLength
+
+	blockchain, engine, _, err := core.NewDexonChain(db, genesis)
+	if err != nil {
+		return nil, err
+	}
+	config := Config{PrivateKey: nodeKey, DevMode: true}
+
+	dex := &Dexon{
+		chainDb:     db,
+		chainConfig: genesis.Config,
+		engine:      engine,
+		blockchain:  blockchain,
+	}
+	dex.txPool = core.NewTxPool(core.DefaultTxPoolConfig, genesis.Config, dex.blockchain)
+
+	dex.APIBackend = &DexAPIBackend{dex, nil}
+	dex.governance = NewDexconGovernance(dex.APIBackend, dex.chainConfig, config.PrivateKey)
+	engine.SetGovStateFetcher(core.NewSoleNotaryGovStateFetcher(dex.governance, &nodeKey.PublicKey))
+	dex.app = NewDexconApp(dex.txPool, dex.blockchain, dex.governance, db, &config)
+	dex.sealer = newDevSealer(dex, nodeKey, 0)
+	return dex, nil
+}
+
+// Tests that the developer sealer seals the pending transactions and moves
+// through the rounds, with the sole notary rewarded and never disqualified.
+func TestDevSealerRounds(t *testing.T) {
+	nodeKey, _ := crypto.GenerateKey()
+	faucetKey, _ := crypto.GenerateKey()
+	faucet := crypto.PubkeyToAddress(faucetKey.PublicKey)
+
+	dex, err := newDevDexon(nodeKey, faucet, 5)
+	if err != nil {
+		t.Fatalf("New dexon fail: %v", err)
+	}
+	defer dex.txPool.Stop()
+	defer dex.blockchain.Stop()
+
+	signer := types.NewEIP155Signer(dex.chainConfig.ChainID)
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := types.NewTransaction(0, to, big.NewInt(1000), 21000, dex.chainConfig.Dexcon.MinGasPrice, nil)
+	if tx, err = types.SignTx(tx, signer, faucetKey); err != nil {
+		t.Fatalf("Sign tx fail: %v", err)
+	}
+	if err := dex.txPool.AddLocal(tx); err != nil {
+		t.Fatalf("Add tx fail: %v", err)
+	}
+	for i := 0; i < 16; i++ {
+		dex.sealer.seal()
+	}
+
+	current := dex.blockchain.CurrentBlock()
+	if current.NumberU64() != 16 {
+		t.Fatalf("sealed height mismatch: have %d, want 16", current.NumberU64())
+	}
+	if block := dex.blockchain.GetBlockByNumber(1); len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != tx.Hash() {
+		t.Errorf("pending transaction not sealed in the first block")
+	}
+	// Round 0 starts at height 0, the following ones once the previous ended
+	if current.Round() != 3 {
+		t.Errorf("round mismatch: have %d, want 3", current.Round())
+	}
+	for round, want := range []uint64{0, 6, 11, 16} {
+		if height, ok := dex.blockchain.GetRoundHeight(uint64(round)); !ok || height != want {
+			t.Errorf("round %d height mismatch: have %d (%v), want %d", round, height, ok, want)
+		}
+	}
+	owner := crypto.PubkeyToAddress(nodeKey.PublicKey)
+	if current.Coinbase() != owner || current.Reward().Sign() <= 0 {
+		t.Errorf("notary not rewarded: coinbase %x, reward %v", current.Coinbase(), current.Reward())
+	}
+	if qualified := len(dex.governance.GetHeadState().QualifiedNodes()); qualified != 1 {
+		t.Errorf("qualified node count mismatch: have %d, want 1", qualified)
+	}
+}
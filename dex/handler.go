@@ -49,6 +49,8 @@ import (
 	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
+	lru "github.com/hashicorp/golang-lru"
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus"
@@ -94,8 +96,18 @@ const (
 // not compatible (low protocol version restrictions and high requirements).
 var errIncompatibleConfig = errors.New("incompatible configuration")
 
+// protocolError is an error caused by a peer violating the protocol.
+type protocolError struct {
+	code errCode
+	msg  string
+}
+
+func (e *protocolError) Error() string {
+	return fmt.Sprintf("%v - %v", e.code, e.msg)
+}
+
 func errResp(code errCode, format string, v ...interface{}) error {
-	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+	return &protocolError{code: code, msg: fmt.Sprintf(format, v...)}
 }
 
 type ProtocolManager struct {
@@ -136,9 +148,15 @@ type ProtocolManager struct {
 	chainHeadSub event.Subscription
 
 	// channels for dexon consensus core
-	receiveCh          chan coreTypes.Msg
-	reportBadPeerChan  chan interface{}
-	receiveCoreMessage int32
+	receiveCh           chan coreTypes.Msg
+	reportBadPeerChan   chan interface{}
+	scores              *peerScores
+	notaryMesh          *notaryMesh
+	recorder            *msgRecorder
+	tsigVerifiers       tsigVerifierGetter
+	verifiedAgreements  *lru.Cache // Positions of the verified agreement results
+	aggregateAgreements bool
+	receiveCoreMessage  int32
 
 	srvr p2pServer
 
@@ -163,7 +181,7 @@ func NewProtocolManager(
 	config *params.ChainConfig, mode downloader.SyncMode, networkID uint64,
 	mux *event.TypeMux, txpool txPool, engine consensus.Engine,
 	blockchain *core.BlockChain, chaindb ethdb.Database, whitelist map[uint64]common.Hash,
-	isBlockProposer bool, gov governance, app dexconApp) (*ProtocolManager, error) {
+	isBlockProposer bool, cacheSize int, cacheRounds uint64, gov governance, app dexconApp) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkID:          networkID,
@@ -171,7 +189,6 @@ func NewProtocolManager(
 		txpool:             txpool,
 		gov:                gov,
 		blockchain:         blockchain,
-		cache:              newCache(5120, dexDB.NewDatabase(chaindb)),
 		nextPullVote:       &sync.Map{},
 		nextPullBlock:      &sync.Map{},
 		chainconfig:        config,
@@ -182,11 +199,18 @@ func NewProtocolManager(
 		quitSync:           make(chan struct{}),
 		receiveCh:          make(chan coreTypes.Msg, 1024),
 		reportBadPeerChan:  make(chan interface{}, 128),
+		scores:             newPeerScores(chaindb),
 		receiveCoreMessage: 0,
 		isBlockProposer:    isBlockProposer,
 		app:                app,
 		blockNumberGauge:   metrics.GetOrRegisterGauge("dex/blocknumber", nil),
 	}
+	manager.verifiedAgreements, _ = lru.New(verifiedAgreementsLimit)
+	if cacheRounds > 0 {
+		manager.cache = newPersistentCache(cacheSize, dexDB.NewDatabase(chaindb), chaindb, cacheRounds)
+	} else {
+		manager.cache = newCache(cacheSize, dexDB.NewDatabase(chaindb))
+	}
 
 	// Figure out whether to allow fast sync or not
 	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
@@ -281,7 +305,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 func (pm *ProtocolManager) Start(srvr p2pServer, maxPeers int) {
 	pm.maxPeers = maxPeers
 	pm.srvr = srvr
-	pm.peers = newPeerSet(pm.gov, pm.srvr)
+	pm.peers = newPeerSet(pm.gov, pm.srvr, pm.notaryMesh)
 
 	// broadcast transactions
 	pm.txsCh = make(chan core.NewTxsEvent, txChanSize)
@@ -336,6 +360,11 @@ func (pm *ProtocolManager) Stop() {
 	// Wait for all peer handler goroutines and the loops to come down.
 	pm.wg.Wait()
 
+	if pm.recorder != nil {
+		if err := pm.recorder.Close(); err != nil {
+			log.Error("Failed to close consensus record", "err", err)
+		}
+	}
 	log.Info("DEXON protocol stopped")
 }
 
@@ -350,16 +379,57 @@ func (pm *ProtocolManager) ReportBadPeerChan() chan<- interface{} {
 func (pm *ProtocolManager) badPeerWatchLoop() {
 	for {
 		select {
-		case id := <-pm.reportBadPeerChan:
-			log.Debug("Bad peer detected, removing", "id", id.(string))
-			pm.removePeer(id.(string))
+		case report := <-pm.reportBadPeerChan:
+			// Only faults verified by the protocol manager are penalized, the
+			// consensus core also reports peers when the local node is
+			// unable to verify their messages, e.g. while syncing.
+			switch r := report.(type) {
+			case peerFault:
+				log.Debug("Bad peer detected, removing", "id", r.id, "offense", r.offense)
+				pm.scores.penalize(r.id, r.offense)
+				pm.removePeer(r.id)
+			case coreMsgSource:
+				log.Debug("Bad peer detected, removing", "id", r.id, "offense", r.offense)
+				pm.removePeer(r.id)
+			case string:
+				log.Debug("Bad peer detected, removing", "id", r)
+				pm.removePeer(r)
+			default:
+				log.Warn("Unknown bad peer report", "report", report)
+			}
 		case <-pm.quitSync:
 			return
 		}
 	}
 }
 
+// reportPeerFault reports a fault of peer id found by the protocol manager,
+// without blocking the message handler.
+func (pm *ProtocolManager) reportPeerFault(id string, offense peerOffense) {
+	select {
+	case pm.reportBadPeerChan <- peerFault{id, offense}:
+	default:
+	}
+}
+
+// verifyCoreBlock checks the hash of block and, unless it is empty, its
+// signature by its proposer, which don't depend on the state of the local
+// node. The payload is not checked, as it is not always carried along.
+func verifyCoreBlock(block *coreTypes.Block) bool {
+	if block.IsEmpty() {
+		hash, err := coreUtils.HashBlock(block)
+		return err == nil && hash == block.Hash
+	}
+	return coreUtils.VerifyBlockSignatureWithoutPayload(block) == nil
+}
+
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	if pv >= dex65 {
+		rw = &compressingMsgReadWriter{MsgReadWriter: rw}
+	}
+	if pm.recorder != nil {
+		rw = &recordingMsgReadWriter{MsgReadWriter: rw, recorder: pm.recorder, peer: p.ID().String()}
+	}
 	return newPeer(pv, p, newMeteredMsgWriter(rw))
 }
 
@@ -370,6 +440,10 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
 		return p2p.DiscTooManyPeers
 	}
+	if pm.scores.banned(p.id) {
+		p.Log().Debug("Banned peer rejected")
+		return p2p.DiscUselessPeer
+	}
 	p.Log().Debug("Ethereum peer connected", "name", p.Name())
 
 	// Execute the Ethereum handshake
@@ -412,6 +486,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	for {
 		if err := pm.handleMsg(p); err != nil {
 			p.Log().Debug("Ethereum message handling failed", "err", err)
+			if _, ok := err.(*protocolError); ok {
+				pm.scores.penalize(p.id, offenseProtocol)
+			}
 			return err
 		}
 	}
@@ -859,10 +936,17 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&blocks); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		for _, block := range blocks {
+			if !verifyCoreBlock(block) {
+				p.Log().Debug("Invalid core block", "block", block)
+				pm.reportPeerFault(p.id, offenseInvalidBlock)
+				return nil
+			}
+		}
 		pm.cache.addBlocks(blocks)
 		for _, block := range blocks {
 			pm.receiveCh <- coreTypes.Msg{
-				PeerID:  p.ID().String(),
+				PeerID:  coreMsgSource{p.id, offenseInvalidBlock},
 				Payload: block,
 			}
 		}
@@ -874,12 +958,19 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&votes); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		for _, vote := range votes {
+			if ok, err := coreUtils.VerifyVoteSignature(vote); !ok || err != nil {
+				p.Log().Debug("Invalid vote", "vote", vote)
+				pm.reportPeerFault(p.id, offenseInvalidVote)
+				return nil
+			}
+		}
 		for _, vote := range votes {
 			if vote.Type >= coreTypes.VotePreCom {
 				pm.cache.addVote(vote)
 			}
 			pm.receiveCh <- coreTypes.Msg{
-				PeerID:  p.ID().String(),
+				PeerID:  coreMsgSource{p.id, offenseInvalidVote},
 				Payload: vote,
 			}
 		}
@@ -893,14 +984,31 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
 		p.MarkAgreement(agreement.Position)
+		// Agreement results may be relayed without their votes, check the
+		// threshold signature before trusting the randomness.
+		verified := true
+		if pm.tsigVerifiers != nil {
+			switch err := pm.verifyAgreement(&agreement); err {
+			case nil:
+			case errInvalidAgreementTSig:
+				p.Log().Debug("Invalid agreement result", "agreement", &agreement)
+				pm.reportPeerFault(p.id, offenseInvalidAgreement)
+				return nil
+			default:
+				p.Log().Debug("Unable to verify agreement result", "agreement", &agreement, "err", err)
+				verified = false
+			}
+		}
 		// Update randomness field for blocks in cache.
-		block := pm.cache.blocks(coreCommon.Hashes{agreement.BlockHash}, false)
-		if len(block) != 0 {
-			block[0].Randomness = agreement.Randomness
-			pm.cache.addFinalizedBlock(block[0])
+		if verified {
+			block := pm.cache.blocks(coreCommon.Hashes{agreement.BlockHash}, false)
+			if len(block) != 0 {
+				block[0].Randomness = agreement.Randomness
+				pm.cache.addFinalizedBlock(block[0])
+			}
 		}
 		pm.receiveCh <- coreTypes.Msg{
-			PeerID:  p.ID().String(),
+			PeerID:  coreMsgSource{p.id, offenseInvalidAgreement},
 			Payload: &agreement,
 		}
 	case msg.Code == DKGPrivateShareMsg:
@@ -912,9 +1020,14 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&ps); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		if ok, err := coreUtils.VerifyDKGPrivateShareSignature(&ps); !ok || err != nil {
+			p.Log().Debug("Invalid DKG private share", "share", &ps)
+			pm.reportPeerFault(p.id, offenseInvalidDKG)
+			return nil
+		}
 		p.MarkDKGPrivateShares(rlpHash(ps))
 		pm.receiveCh <- coreTypes.Msg{
-			PeerID:  p.ID().String(),
+			PeerID:  coreMsgSource{p.id, offenseInvalidDKG},
 			Payload: &ps,
 		}
 	case msg.Code == DKGPartialSignatureMsg:
@@ -926,8 +1039,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&psig); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		if ok, err := coreUtils.VerifyDKGPartialSignatureSignature(&psig); !ok || err != nil {
+			p.Log().Debug("Invalid DKG partial signature", "psig", &psig)
+			pm.reportPeerFault(p.id, offenseInvalidDKG)
+			return nil
+		}
 		pm.receiveCh <- coreTypes.Msg{
-			PeerID:  p.ID().String(),
+			PeerID:  coreMsgSource{p.id, offenseInvalidDKG},
 			Payload: &psig,
 		}
 	case msg.Code == PullBlocksMsg:
@@ -1149,6 +1267,9 @@ func (pm *ProtocolManager) BroadcastAgreementResult(
 		block[0].Randomness = agreement.Randomness
 		pm.cache.addFinalizedBlock(block[0])
 	}
+	if pm.aggregateAgreements {
+		agreement = aggregatedAgreement(agreement)
+	}
 
 	// send to notary nodes first (direct)
 	label := peerLabel{
@@ -1324,6 +1445,10 @@ func (pm *ProtocolManager) peerSetLoop() {
 		select {
 		case event := <-pm.chainHeadCh:
 			pm.blockNumberGauge.Update(int64(event.Block.NumberU64()))
+			pm.cache.setTip(coreTypes.Position{
+				Round:  event.Block.Round(),
+				Height: event.Block.NumberU64(),
+			})
 
 			if !pm.isBlockProposer {
 				break
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core"
@@ -444,3 +445,31 @@ func testGetReceipt(t *testing.T, protocol int) {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
+
+// Tests that only the faults verified by the protocol manager are penalized,
+// while the peers reported by the consensus core are just dropped.
+func TestBadPeerReports(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	pm.ReportBadPeerChan() <- coreMsgSource{"core", offenseInvalidBlock}
+	pm.ReportBadPeerChan() <- "plain"
+	pm.ReportBadPeerChan() <- peerFault{"fault", offenseInvalidAgreement}
+
+	// Reports are handled in order, so the others are done once the fault
+	// is scored.
+	deadline := time.Now().Add(time.Second)
+	for {
+		scores := pm.scores.peerScores()
+		if len(scores) != 0 {
+			if len(scores) != 1 || scores[0].ID != "fault" {
+				t.Fatalf("penalized peers mismatch: %v", scores)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("peer fault not penalized")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
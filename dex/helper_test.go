@@ -27,6 +27,9 @@ import (
 	"sync"
 	"testing"
 
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
+
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus/ethash"
 	"github.com/dexon-foundation/dexon/core"
@@ -46,22 +49,30 @@ var (
 	testBank       = crypto.PubkeyToAddress(testBankKey.PublicKey)
 )
 
+// newTestCoreSigner returns a signer of consensus messages with a new key.
+func newTestCoreSigner() *coreUtils.Signer {
+	key, _ := crypto.GenerateKey()
+	return coreUtils.NewSigner(coreEcdsa.NewPrivateKeyFromECDSA(key))
+}
+
 // testP2PServer is a fake, helper p2p server for testing purposes.
 type testP2PServer struct {
-	mu      sync.Mutex
-	self    *enode.Node
-	privkey *ecdsa.PrivateKey
-	direct  map[enode.ID]*enode.Node
-	group   map[string][]*enode.Node
+	mu       sync.Mutex
+	self     *enode.Node
+	privkey  *ecdsa.PrivateKey
+	direct   map[enode.ID]*enode.Node
+	priority map[enode.ID]int
+	group    map[string][]*enode.Node
 }
 
 func newTestP2PServer(privkey *ecdsa.PrivateKey) *testP2PServer {
 	self := enode.NewV4(&privkey.PublicKey, net.IP{}, 0, 0)
 	return &testP2PServer{
-		self:    self,
-		privkey: privkey,
-		direct:  make(map[enode.ID]*enode.Node),
-		group:   make(map[string][]*enode.Node),
+		self:     self,
+		privkey:  privkey,
+		direct:   make(map[enode.ID]*enode.Node),
+		priority: make(map[enode.ID]int),
+		group:    make(map[string][]*enode.Node),
 	}
 }
 
@@ -73,10 +84,11 @@ func (s *testP2PServer) GetPrivateKey() *ecdsa.PrivateKey {
 	return s.privkey
 }
 
-func (s *testP2PServer) AddDirectPeer(node *enode.Node) {
+func (s *testP2PServer) AddPriorityDirectPeer(node *enode.Node, priority int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.direct[node.ID()] = node
+	s.priority[node.ID()] = priority
 }
 
 func (s *testP2PServer) RemoveDirectPeer(node *enode.Node) {
@@ -133,7 +145,7 @@ func newTestProtocolManager(mode downloader.SyncMode, blocks int, generator func
 		notarySetFunc: func(uint64) (map[string]struct{}, error) { return nil, nil },
 	}
 
-	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, evmux, &testTxPool{added: newtx}, engine, blockchain, db, nil, true, tgov, &testApp{})
+	pm, err := NewProtocolManager(gspec.Config, mode, DefaultConfig.NetworkId, evmux, &testTxPool{added: newtx}, engine, blockchain, db, nil, true, DefaultConfig.ConsensusCache*1024*1024, 0, tgov, &testApp{})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -79,6 +79,17 @@ var (
 	miscInTrafficMeter                     = metrics.NewRegisteredMeter("dex/misc/in/traffic", nil)
 	miscOutPacketsMeter                    = metrics.NewRegisteredMeter("dex/misc/out/packets", nil)
 	miscOutTrafficMeter                    = metrics.NewRegisteredMeter("dex/misc/out/traffic", nil)
+	cacheVoteHitMeter                      = metrics.NewRegisteredMeter("dex/cache/votes/hit", nil)
+	cacheVoteMissMeter                     = metrics.NewRegisteredMeter("dex/cache/votes/miss", nil)
+	cacheBlockHitMeter                     = metrics.NewRegisteredMeter("dex/cache/blocks/hit", nil)
+	cacheBlockMissMeter                    = metrics.NewRegisteredMeter("dex/cache/blocks/miss", nil)
+	cacheEvictionMeter                     = metrics.NewRegisteredMeter("dex/cache/evictions", nil)
+	cacheEvictedBytesMeter                 = metrics.NewRegisteredMeter("dex/cache/evictions/bytes", nil)
+	cacheSizeGauge                         = metrics.NewRegisteredGauge("dex/cache/size", nil)
+	compressedInTrafficMeter               = metrics.NewRegisteredMeter("dex/compression/in/compressed", nil)
+	decompressedInTrafficMeter             = metrics.NewRegisteredMeter("dex/compression/in/decompressed", nil)
+	compressedOutTrafficMeter              = metrics.NewRegisteredMeter("dex/compression/out/compressed", nil)
+	decompressedOutTrafficMeter            = metrics.NewRegisteredMeter("dex/compression/out/decompressed", nil)
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -0,0 +1,143 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dexon-foundation/dexon/p2p/enode"
+)
+
+// Dial priorities of the direct peers of a label.
+const (
+	groupConnPriority  = iota // nodes of a group we are not a member of
+	directConnPriority        // nodes of a group we are a member of
+	linkPriority              // nodes of preferred links, plus the link priority
+)
+
+// NotaryMeshConfig declares the preferred links to known notary nodes, and
+// limits the connections made to the nodes of each peer label.
+type NotaryMeshConfig struct {
+	Links          []NotaryLink
+	MaxDirectPeers int // Maximum number of direct connections per label we are a member of (0 = unlimited)
+	GroupPeers     int // Number of connections per label we are not a member of (0 = default)
+}
+
+// NotaryLink is a preferred link to a known notary node.
+type NotaryLink struct {
+	Node     string // Enode URL of the node, with the address to dial it at
+	Priority int    // Links of higher priority are dialed first
+}
+
+type notaryLink struct {
+	node     *enode.Node
+	priority int
+}
+
+// notaryMesh is the parsed notary mesh config. The nil notaryMesh resolves
+// nodes through discovery and dials them without preference.
+type notaryMesh struct {
+	links          map[string]*notaryLink
+	maxDirectPeers int
+	groupPeers     int
+}
+
+func newNotaryMesh(config NotaryMeshConfig) (*notaryMesh, error) {
+	if config.MaxDirectPeers < 0 || config.GroupPeers < 0 {
+		return nil, fmt.Errorf("negative notary connection limit")
+	}
+	m := &notaryMesh{
+		links:          make(map[string]*notaryLink, len(config.Links)),
+		maxDirectPeers: config.MaxDirectPeers,
+		groupPeers:     config.GroupPeers,
+	}
+	for _, link := range config.Links {
+		node, err := enode.ParseV4(link.Node)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notary link %q: %v", link.Node, err)
+		}
+		if node.Incomplete() {
+			return nil, fmt.Errorf("notary link %q has no address", link.Node)
+		}
+		if link.Priority < 0 {
+			return nil, fmt.Errorf("notary link %q has negative priority", link.Node)
+		}
+		m.links[node.ID().String()] = &notaryLink{node: node, priority: link.Priority}
+	}
+	return m, nil
+}
+
+// node returns the node to dial for n, at the address of its link if any.
+func (m *notaryMesh) node(n *enode.Node) *enode.Node {
+	if m == nil {
+		return n
+	}
+	if link, ok := m.links[n.ID().String()]; ok {
+		return link.node
+	}
+	return n
+}
+
+// priority returns the dial priority of node id for a label, depending on
+// whether we are a member of the label.
+func (m *notaryMesh) priority(id string, member bool) int {
+	if m != nil {
+		if link, ok := m.links[id]; ok {
+			return linkPriority + link.priority
+		}
+	}
+	if member {
+		return directConnPriority
+	}
+	return groupConnPriority
+}
+
+// order returns the ids of nodes by descending dial priority.
+func (m *notaryMesh) order(nodes map[string]*enode.Node, member bool) []string {
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		pi, pj := m.priority(ids[i], member), m.priority(ids[j], member)
+		if pi != pj {
+			return pi > pj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// directLimit returns the maximum number of direct connections per label we
+// are a member of, 0 meaning unlimited.
+func (m *notaryMesh) directLimit() int {
+	if m == nil {
+		return 0
+	}
+	return m.maxDirectPeers
+}
+
+// groupLimit returns the number of connections per label we are not a
+// member of.
+func (m *notaryMesh) groupLimit() int {
+	if m == nil || m.groupPeers == 0 {
+		return groupConnNum
+	}
+	return m.groupPeers
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/types"
+)
+
+// PayloadPolicy decides which pending transactions a block proposer offers
+// for its payload, and in which order. Transactions offered are still
+// validated by the proposer and dropped if they do not fit into the block.
+type PayloadPolicy interface {
+	// Transactions returns the candidate transactions of a payload. pending
+	// holds the executable transactions of each sender in nonce order,
+	// starting at the next nonce expected by the chain. The policy may
+	// modify pending.
+	Transactions(signer types.Signer, pending map[common.Address]types.Transactions) PayloadTransactions
+}
+
+// PayloadTransactions is an ordered set of candidate payload transactions,
+// iterated like types.TransactionsByPriceAndNonce.
+type PayloadTransactions interface {
+	// Peek returns the next candidate, or nil if there is none left.
+	Peek() *types.Transaction
+	// Shift moves on to the next candidate after the current one is included.
+	Shift()
+	// Pop drops the current candidate along with the following transactions
+	// of the same sender.
+	Pop()
+}
+
+// PayloadPolicyConfig holds the settings of the payload policy.
+type PayloadPolicyConfig struct {
+	MaxTxsPerSender int              // Maximum number of transactions of a sender in a payload (0 = unlimited)
+	Locals          []common.Address // Senders whose transactions are included before the others
+	Blacklist       []common.Address // Recipients whose transactions are never included
+}
+
+// NewPayloadPolicy returns the payload policy described by config. An empty
+// config results in the default policy.
+func NewPayloadPolicy(config PayloadPolicyConfig) PayloadPolicy {
+	if config.MaxTxsPerSender <= 0 && len(config.Locals) == 0 && len(config.Blacklist) == 0 {
+		return DefaultPayloadPolicy{}
+	}
+	policy := &configuredPayloadPolicy{
+		maxTxsPerSender: config.MaxTxsPerSender,
+		locals:          make(map[common.Address]struct{}, len(config.Locals)),
+		blacklist:       make(map[common.Address]struct{}, len(config.Blacklist)),
+	}
+	for _, addr := range config.Locals {
+		policy.locals[addr] = struct{}{}
+	}
+	for _, addr := range config.Blacklist {
+		policy.blacklist[addr] = struct{}{}
+	}
+	return policy
+}
+
+// DefaultPayloadPolicy offers all pending transactions by gas price, keeping
+// the nonce order of each sender.
+type DefaultPayloadPolicy struct{}
+
+// Transactions implements PayloadPolicy.
+func (DefaultPayloadPolicy) Transactions(signer types.Signer,
+	pending map[common.Address]types.Transactions) PayloadTransactions {
+	return types.NewTransactionsByPriceAndNonce(signer, pending)
+}
+
+// configuredPayloadPolicy caps and filters the transactions of each sender,
+// then offers the transactions of local senders by gas price before the
+// transactions of the others.
+type configuredPayloadPolicy struct {
+	maxTxsPerSender int
+	locals          map[common.Address]struct{}
+	blacklist       map[common.Address]struct{}
+}
+
+// Transactions implements PayloadPolicy.
+func (p *configuredPayloadPolicy) Transactions(signer types.Signer,
+	pending map[common.Address]types.Transactions) PayloadTransactions {
+	locals := make(map[common.Address]types.Transactions)
+	for addr, txs := range pending {
+		// A blacklisted transaction blocks the following ones of its sender.
+		for i, tx := range txs {
+			if tx.To() == nil {
+				continue
+			}
+			if _, ok := p.blacklist[*tx.To()]; ok {
+				txs = txs[:i]
+				break
+			}
+		}
+		if p.maxTxsPerSender > 0 && len(txs) > p.maxTxsPerSender {
+			txs = txs[:p.maxTxsPerSender]
+		}
+		if len(txs) == 0 {
+			delete(pending, addr)
+			continue
+		}
+		if _, ok := p.locals[addr]; ok {
+			locals[addr] = txs
+			delete(pending, addr)
+			continue
+		}
+		pending[addr] = txs
+	}
+	return &payloadTransactionsChain{
+		types.NewTransactionsByPriceAndNonce(signer, locals),
+		types.NewTransactionsByPriceAndNonce(signer, pending),
+	}
+}
+
+// payloadTransactionsChain offers the candidates of each set in turn.
+type payloadTransactionsChain []PayloadTransactions
+
+func (c *payloadTransactionsChain) Peek() *types.Transaction {
+	for len(*c) > 0 {
+		if tx := (*c)[0].Peek(); tx != nil {
+			return tx
+		}
+		*c = (*c)[1:]
+	}
+	return nil
+}
+
+func (c *payloadTransactionsChain) Shift() {
+	(*c)[0].Shift()
+}
+
+func (c *payloadTransactionsChain) Pop() {
+	(*c)[0].Pop()
+}
@@ -0,0 +1,68 @@
+package dex
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+func TestConfiguredPayloadPolicy(t *testing.T) {
+	var (
+		signer    = types.NewEIP155Signer(big.NewInt(1))
+		keys      = make([]*ecdsa.PrivateKey, 3)
+		addrs     = make([]common.Address, 3)
+		target    = common.HexToAddress("0x01")
+		blacklist = common.HexToAddress("0x02")
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	newTxs := func(key *ecdsa.PrivateKey, price int64, to ...common.Address) types.Transactions {
+		txs := make(types.Transactions, len(to))
+		for nonce := range to {
+			tx := types.NewTransaction(uint64(nonce), to[nonce], big.NewInt(1), 21000, big.NewInt(price), nil)
+			txs[nonce], _ = types.SignTx(tx, signer, key)
+		}
+		return txs
+	}
+	pending := map[common.Address]types.Transactions{
+		addrs[0]: newTxs(keys[0], 3, target, target, target),
+		addrs[1]: newTxs(keys[1], 2, target, blacklist, target),
+		addrs[2]: newTxs(keys[2], 1, target, target),
+	}
+	policy := NewPayloadPolicy(PayloadPolicyConfig{
+		MaxTxsPerSender: 2,
+		Locals:          []common.Address{addrs[2]},
+		Blacklist:       []common.Address{blacklist},
+	})
+
+	want := []struct {
+		from  common.Address
+		nonce uint64
+	}{{addrs[2], 0}, {addrs[2], 1}, {addrs[0], 0}, {addrs[0], 1}, {addrs[1], 0}}
+	txs := policy.Transactions(signer, pending)
+	for i, w := range want {
+		tx := txs.Peek()
+		if tx == nil {
+			t.Fatalf("tx %d missing", i)
+		}
+		from, _ := types.Sender(signer, tx)
+		if from != w.from || tx.Nonce() != w.nonce {
+			t.Errorf("tx %d mismatch: have %x nonce %d, want %x nonce %d",
+				i, from, tx.Nonce(), w.from, w.nonce)
+		}
+		txs.Shift()
+	}
+	if tx := txs.Peek(); tx != nil {
+		t.Errorf("unexpected tx %x", tx.Hash())
+	}
+
+	if _, ok := NewPayloadPolicy(PayloadPolicyConfig{}).(DefaultPayloadPolicy); !ok {
+		t.Errorf("empty config does not result in the default policy")
+	}
+}
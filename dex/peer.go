@@ -668,6 +668,7 @@ type peerSet struct {
 
 	srvr p2pServer
 	gov  governance
+	mesh *notaryMesh
 
 	label2Nodes    map[peerLabel]map[string]*enode.Node
 	directConn     map[peerLabel]struct{}
@@ -676,11 +677,12 @@ type peerSet struct {
 }
 
 // newPeerSet creates a new peer set to track the active participants.
-func newPeerSet(gov governance, srvr p2pServer) *peerSet {
+func newPeerSet(gov governance, srvr p2pServer, mesh *notaryMesh) *peerSet {
 	return &peerSet{
 		peers:          make(map[string]*peer),
 		gov:            gov,
 		srvr:           srvr,
+		mesh:           mesh,
 		selfPK:         hex.EncodeToString(crypto.FromECDSAPub(&srvr.GetPrivateKey().PublicKey)),
 		label2Nodes:    make(map[peerLabel]map[string]*enode.Node),
 		directConn:     make(map[peerLabel]struct{}),
@@ -918,16 +920,31 @@ func (ps *peerSet) EnsureGroupConn() {
 	now := time.Now()
 	for label, peers := range ps.groupConnPeers {
 		// Remove timeout group conn peer.
+		expired := make(map[string]struct{})
 		for id, addtime := range peers {
 			if ps.peers[id] == nil && time.Since(addtime) > groupConnTimeout {
 				ps.removeDirectPeer(id, label)
 				delete(ps.groupConnPeers[label], id)
+				expired[id] = struct{}{}
 			}
 		}
 
-		// Add new group conn peer.
-		for id := range ps.label2Nodes[label] {
-			if len(ps.groupConnPeers[label]) >= groupConnNum {
+		// Add new group conn peer, trying the expired ones last.
+		for _, id := range ps.mesh.order(ps.label2Nodes[label], false) {
+			if len(ps.groupConnPeers[label]) >= ps.mesh.groupLimit() {
+				break
+			}
+			if _, ok := peers[id]; ok {
+				continue
+			}
+			if _, ok := expired[id]; ok {
+				continue
+			}
+			ps.groupConnPeers[label][id] = now
+			ps.addDirectPeer(id, label)
+		}
+		for id := range expired {
+			if len(ps.groupConnPeers[label]) >= ps.mesh.groupLimit() {
 				break
 			}
 			ps.groupConnPeers[label][id] = now
@@ -938,7 +955,15 @@ func (ps *peerSet) EnsureGroupConn() {
 
 func (ps *peerSet) buildDirectConn(label peerLabel) {
 	ps.directConn[label] = struct{}{}
-	for id := range ps.label2Nodes[label] {
+	self := ps.srvr.Self().ID().String()
+	connected := 0
+	for _, id := range ps.mesh.order(ps.label2Nodes[label], true) {
+		if id != self {
+			if limit := ps.mesh.directLimit(); limit > 0 && connected >= limit {
+				continue
+			}
+			connected++
+		}
 		ps.addDirectPeer(id, label)
 	}
 }
@@ -953,12 +978,12 @@ func (ps *peerSet) forgetDirectConn(label peerLabel) {
 func (ps *peerSet) buildGroupConn(label peerLabel) {
 	peers := make(map[string]time.Time)
 	now := time.Now()
-	for id := range ps.label2Nodes[label] {
-		peers[id] = now
-		ps.addDirectPeer(id, label)
-		if len(peers) >= groupConnNum {
+	for _, id := range ps.mesh.order(ps.label2Nodes[label], false) {
+		if len(peers) >= ps.mesh.groupLimit() {
 			break
 		}
+		peers[id] = now
+		ps.addDirectPeer(id, label)
 	}
 	ps.groupConnPeers[label] = peers
 }
@@ -977,7 +1002,8 @@ func (ps *peerSet) addDirectPeer(id string, label peerLabel) {
 	}
 	ps.allDirectPeers[id] = map[peerLabel]struct{}{label: {}}
 	node := ps.label2Nodes[label][id]
-	ps.srvr.AddDirectPeer(node)
+	_, member := ps.directConn[label]
+	ps.srvr.AddPriorityDirectPeer(node, ps.mesh.priority(id, member))
 }
 
 func (ps *peerSet) removeDirectPeer(id string, label peerLabel) {
@@ -998,6 +1024,8 @@ func (ps *peerSet) pksToNodes(pks map[string]struct{}) map[string]*enode.Node {
 		n := ps.newEmptyNode(pk)
 		if n.ID() == ps.srvr.Self().ID() {
 			n = ps.srvr.Self()
+		} else {
+			n = ps.mesh.node(n)
 		}
 		nodes[n.ID().String()] = n
 	}
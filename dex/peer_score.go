@@ -0,0 +1,288 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+)
+
+const (
+	peerScoreHalfLife = 10 * time.Minute // Time for the penalty score of a peer to decay by half
+	peerBanThreshold  = 100              // Penalty score a peer gets banned at
+	peerBanDuration   = time.Hour        // Duration of the ban of a peer
+	maxPeerScores     = 1024             // Maximum number of peer scores kept
+)
+
+// peerOffense is a kind of misbehaviour of a peer.
+type peerOffense int
+
+const (
+	offenseInvalidBlock peerOffense = iota
+	offenseInvalidVote
+	offenseInvalidAgreement
+	offenseInvalidDKG
+	offenseProtocol
+)
+
+// offensePenalties is the penalty score of each kind of offense.
+var offensePenalties = [...]float64{
+	offenseInvalidBlock:     25,
+	offenseInvalidVote:      10,
+	offenseInvalidAgreement: 25,
+	offenseInvalidDKG:       25,
+	offenseProtocol:         20,
+}
+
+func (o peerOffense) String() string {
+	switch o {
+	case offenseInvalidBlock:
+		return "invalid block"
+	case offenseInvalidVote:
+		return "invalid vote"
+	case offenseInvalidAgreement:
+		return "invalid agreement"
+	case offenseInvalidDKG:
+		return "invalid DKG message"
+	case offenseProtocol:
+		return "protocol error"
+	}
+	return "unknown"
+}
+
+// coreMsgSource identifies the peer a message passed to the consensus core
+// came from. The core reports it back on ReportBadPeerChan if the message
+// turns out to be invalid.
+type coreMsgSource struct {
+	id      string
+	offense peerOffense
+}
+
+func (s coreMsgSource) String() string {
+	return s.id
+}
+
+// peerFault is a report of a peer sending a message the protocol manager
+// found invalid by itself, e.g. with a mismatching signature or hash. Unlike
+// the reports of the consensus core, which may fail to verify a message
+// because the local node is behind, it is always the fault of the peer.
+type peerFault struct {
+	id      string
+	offense peerOffense
+}
+
+// PeerBan is a temporary ban of a misbehaving peer.
+type PeerBan struct {
+	ID     string    `json:"id"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"` // offense which got the peer banned
+}
+
+// PeerScore is the penalty score of a peer.
+type PeerScore struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Offenses map[string]int `json:"offenses"`
+}
+
+type peerScore struct {
+	score    float64
+	updated  time.Time
+	offenses map[peerOffense]int
+}
+
+// decay lowers the score by the time elapsed since its last update.
+func (s *peerScore) decay(now time.Time) {
+	elapsed := now.Sub(s.updated)
+	if elapsed > 0 {
+		s.score *= math.Pow(0.5, float64(elapsed)/float64(peerScoreHalfLife))
+	}
+	s.updated = now
+}
+
+// peerScores keeps the penalty scores of the peers, which decay over time,
+// and bans the peers whose score reaches peerBanThreshold. Bans are persisted
+// in the database so that they survive restarts.
+type peerScores struct {
+	lock   sync.Mutex
+	db     ethdb.Database
+	scores map[string]*peerScore
+	bans   map[string]*PeerBan
+	now    func() time.Time
+}
+
+func newPeerScores(db ethdb.Database) *peerScores {
+	s := &peerScores{
+		db:     db,
+		scores: make(map[string]*peerScore),
+		bans:   make(map[string]*PeerBan),
+		now:    time.Now,
+	}
+	for _, ban := range rawdb.ReadPeerBans(db) {
+		s.bans[ban.ID] = &PeerBan{
+			ID:     ban.ID,
+			Until:  time.Unix(int64(ban.Expiry), 0),
+			Reason: ban.Reason,
+		}
+	}
+	return s
+}
+
+// penalize adds the penalty of offense to the score of peer id, and bans it
+// if the score reaches the threshold. It reports whether the peer got banned.
+func (s *peerScores) penalize(id string, offense peerOffense) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	score, exist := s.scores[id]
+	if !exist {
+		s.prune(now)
+		score = &peerScore{updated: now, offenses: make(map[peerOffense]int)}
+		s.scores[id] = score
+	}
+	score.decay(now)
+	score.score += offensePenalties[offense]
+	score.offenses[offense]++
+	if score.score < peerBanThreshold {
+		return false
+	}
+	log.Warn("Banning misbehaving peer", "id", id, "offense", offense, "duration", peerBanDuration)
+	delete(s.scores, id)
+	s.bans[id] = &PeerBan{ID: id, Until: now.Add(peerBanDuration), Reason: offense.String()}
+	s.persist()
+	return true
+}
+
+// banned reports whether peer id is banned.
+func (s *peerScores) banned(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ban, exist := s.bans[id]
+	if !exist {
+		return false
+	}
+	if s.now().Before(ban.Until) {
+		return true
+	}
+	delete(s.bans, id)
+	s.persist()
+	return false
+}
+
+// unban lifts the ban of peer id and resets its score. It reports whether
+// the peer was banned.
+func (s *peerScores) unban(id string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.scores, id)
+	if _, exist := s.bans[id]; !exist {
+		return false
+	}
+	delete(s.bans, id)
+	s.persist()
+	return true
+}
+
+// clearBans lifts all bans, returning the number of peers unbanned.
+func (s *peerScores) clearBans() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	n, now := 0, s.now()
+	for _, ban := range s.bans {
+		if now.Before(ban.Until) {
+			n++
+		}
+	}
+	s.bans = make(map[string]*PeerBan)
+	s.persist()
+	return n
+}
+
+// list returns the active bans, sorted by peer id.
+func (s *peerScores) list() []*PeerBan {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	bans := make([]*PeerBan, 0, len(s.bans))
+	for _, ban := range s.bans {
+		if now.Before(ban.Until) {
+			b := *ban
+			bans = append(bans, &b)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].ID < bans[j].ID })
+	return bans
+}
+
+// peerScores returns the scores of the peers penalized recently, sorted by
+// peer id. Scores decayed to nearly zero are dropped.
+func (s *peerScores) peerScores() []*PeerScore {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(s.now())
+	scores := make([]*PeerScore, 0, len(s.scores))
+	for id, score := range s.scores {
+		offenses := make(map[string]int, len(score.offenses))
+		for offense, n := range score.offenses {
+			offenses[offense.String()] = n
+		}
+		scores = append(scores, &PeerScore{ID: id, Score: score.score, Offenses: offenses})
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].ID < scores[j].ID })
+	return scores
+}
+
+// prune drops the scores decayed to nearly zero. If there are still
+// maxPeerScores scores left, the lowest one is dropped to make room for a new
+// one. The lock must be held.
+func (s *peerScores) prune(now time.Time) {
+	lowest := ""
+	for id, score := range s.scores {
+		score.decay(now)
+		if score.score < 1 {
+			delete(s.scores, id)
+			continue
+		}
+		if lowest == "" || score.score < s.scores[lowest].score {
+			lowest = id
+		}
+	}
+	if len(s.scores) >= maxPeerScores {
+		delete(s.scores, lowest)
+	}
+}
+
+// persist writes the bans to the database, the lock must be held.
+func (s *peerScores) persist() {
+	bans := make([]rawdb.PeerBan, 0, len(s.bans))
+	for _, ban := range s.bans {
+		bans = append(bans, rawdb.PeerBan{
+			ID:     ban.ID,
+			Expiry: uint64(ban.Until.Unix()),
+			Reason: ban.Reason,
+		})
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].ID < bans[j].ID })
+	rawdb.WritePeerBans(s.db, bans)
+}
@@ -0,0 +1,96 @@
+package dex
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon/ethdb"
+)
+
+func TestPeerScores(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	now := time.Unix(1000000, 0)
+	scores := newPeerScores(db)
+	scores.now = func() time.Time { return now }
+
+	// Penalties decay by half every half-life.
+	scores.penalize("a", offenseInvalidVote)
+	now = now.Add(peerScoreHalfLife)
+	list := scores.peerScores()
+	if len(list) != 1 || list[0].Score != offensePenalties[offenseInvalidVote]/2 {
+		t.Fatalf("decayed score mismatch: %v", list)
+	}
+	if list[0].Offenses[offenseInvalidVote.String()] != 1 {
+		t.Errorf("offenses mismatch: %v", list[0].Offenses)
+	}
+
+	// A peer flooding invalid votes gets banned.
+	banned := false
+	for i := 0; i < 20 && !banned; i++ {
+		banned = scores.penalize("b", offenseInvalidVote)
+	}
+	if !banned || !scores.banned("b") {
+		t.Fatalf("flooding peer not banned")
+	}
+	if scores.banned("a") {
+		t.Errorf("peer a banned")
+	}
+
+	// Bans survive restarts until they expire.
+	restarted := newPeerScores(db)
+	restarted.now = func() time.Time { return now }
+	if bans := restarted.list(); len(bans) != 1 || bans[0].ID != "b" ||
+		bans[0].Reason != offenseInvalidVote.String() {
+		t.Fatalf("persisted bans mismatch: %v", bans)
+	}
+	now = now.Add(peerBanDuration)
+	if restarted.banned("b") {
+		t.Errorf("expired ban still active")
+	}
+
+	scores.penalize("c", offenseInvalidDKG)
+	scores.penalize("c", offenseInvalidDKG)
+	scores.penalize("c", offenseInvalidDKG)
+	scores.penalize("c", offenseInvalidDKG)
+	if !scores.unban("c") || scores.banned("c") {
+		t.Errorf("failed to unban peer")
+	}
+	scores.penalize("d", offenseProtocol)
+	if len(scores.peerScores()) == 0 {
+		t.Errorf("missing score of peer d")
+	}
+	if n := scores.clearBans(); n != 0 {
+		t.Errorf("cleared bans mismatch: have %d, want 0", n)
+	}
+	if bans := newPeerScores(db).list(); len(bans) != 0 {
+		t.Errorf("cleared bans persisted: %v", bans)
+	}
+}
+
+func TestPeerScoresPrune(t *testing.T) {
+	now := time.Unix(1000000, 0)
+	scores := newPeerScores(ethdb.NewMemDatabase())
+	scores.now = func() time.Time { return now }
+
+	// Decayed scores are dropped when a new peer is penalized.
+	scores.penalize("old", offenseInvalidVote)
+	now = now.Add(10 * peerScoreHalfLife)
+	scores.penalize("new", offenseInvalidVote)
+	if _, exist := scores.scores["old"]; exist {
+		t.Errorf("decayed score not pruned")
+	}
+
+	// The lowest score makes room once the scores are full.
+	scores.penalize("new", offenseInvalidVote)
+	for i := 0; len(scores.scores) < maxPeerScores; i++ {
+		scores.penalize(fmt.Sprintf("peer%d", i), offenseInvalidVote)
+	}
+	scores.penalize("extra", offenseInvalidVote)
+	if len(scores.scores) != maxPeerScores {
+		t.Errorf("scores size mismatch: have %d, want %d", len(scores.scores), maxPeerScores)
+	}
+	if _, exist := scores.scores["new"]; !exist {
+		t.Errorf("highest score dropped")
+	}
+}
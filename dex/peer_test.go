@@ -3,6 +3,7 @@ package dex
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
+	"net"
 	"reflect"
 	"testing"
 
@@ -48,7 +49,7 @@ func TestPeerSetBuildAndForgetConn(t *testing.T) {
 		return newTestNodeSet(m[round]), nil
 	}
 
-	ps := newPeerSet(gov, server)
+	ps := newPeerSet(gov, server, nil)
 
 	// build round 10
 	ps.BuildConnection(10)
@@ -221,6 +222,72 @@ func TestPeerSetBuildAndForgetConn(t *testing.T) {
 	}
 }
 
+func TestPeerSetNotaryMesh(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestP2PServer(key)
+	self := server.Self()
+
+	var nodes []*enode.Node
+	for i := 0; i < 4; i++ {
+		nodes = append(nodes, randomV4CompactNode())
+	}
+	linked := enode.NewV4(nodes[2].Pubkey(), net.ParseIP("10.0.0.2"), 30303, 30303)
+	mesh, err := newNotaryMesh(NotaryMeshConfig{
+		Links:          []NotaryLink{{Node: linked.String(), Priority: 5}},
+		MaxDirectPeers: 1,
+		GroupPeers:     1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gov := &testGovernance{}
+	gov.notarySetFunc = func(round uint64) (map[string]struct{}, error) {
+		if round == 10 {
+			return newTestNodeSet([]*enode.Node{self, nodes[1], nodes[2]}), nil
+		}
+		return newTestNodeSet([]*enode.Node{nodes[1], nodes[2], nodes[3]}), nil
+	}
+	ps := newPeerSet(gov, server, mesh)
+
+	// As a member, only the linked node is connected within the limit, at
+	// the address of its link.
+	ps.BuildConnection(10)
+	if _, ok := server.direct[nodes[1].ID()]; ok {
+		t.Errorf("direct peers exceed the limit")
+	}
+	node, ok := server.direct[nodes[2].ID()]
+	if !ok {
+		t.Fatalf("linked node not connected")
+	}
+	if !node.IP().Equal(linked.IP()) || node.TCP() != linked.TCP() {
+		t.Errorf("linked node address mismatch: have %v, want %v", node, linked)
+	}
+	if p := server.priority[nodes[2].ID()]; p != linkPriority+5 {
+		t.Errorf("linked node priority mismatch: have %d, want %d", p, linkPriority+5)
+	}
+
+	// As a non-member, the linked node is preferred for the group connection.
+	ps.ForgetConnection(10)
+	ps.BuildConnection(11)
+	label := peerLabel{set: notaryset, round: 11}
+	if len(ps.groupConnPeers[label]) != 1 {
+		t.Fatalf("group peers mismatch: have %d, want 1", len(ps.groupConnPeers[label]))
+	}
+	if _, ok := ps.groupConnPeers[label][nodes[2].ID().String()]; !ok {
+		t.Errorf("linked node not preferred for group connection")
+	}
+
+	if _, err := newNotaryMesh(NotaryMeshConfig{
+		Links: []NotaryLink{{Node: nodes[3].String()}},
+	}); err == nil {
+		t.Errorf("link without address accepted")
+	}
+}
+
 func newTestNodeSet(nodes []*enode.Node) map[string]struct{} {
 	m := make(map[string]struct{})
 	for _, node := range nodes {
@@ -263,6 +263,9 @@ func TestRecvCoreBlocks(t *testing.T) {
 			Signature: []byte("crs-signature"),
 		},
 	}
+	if err := newTestCoreSigner().SignBlock(&block); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
 
 	if err := p2p.Send(p.app, CoreBlockMsg, []*coreTypes.Block{&block}); err != nil {
 		t.Fatalf("send error: %v", err)
@@ -407,6 +410,9 @@ func TestRecvVotes(t *testing.T) {
 			Signature: []byte("sig"),
 		},
 	}
+	if err := newTestCoreSigner().SignVote(&vote); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
 
 	if err := p2p.Send(p.app, VoteMsg, []*coreTypes.Vote{&vote}); err != nil {
 		t.Fatalf("send error: %v", err)
@@ -423,6 +429,22 @@ func TestRecvVotes(t *testing.T) {
 	case <-time.After(1 * time.Second):
 		t.Errorf("no vote received within 1 seconds")
 	}
+
+	// Votes not signed by their proposer are dropped, and their sender
+	// penalized.
+	vote.Period++
+	if err := p2p.Send(p.app, VoteMsg, []*coreTypes.Vote{&vote}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case <-ch:
+		t.Errorf("invalid vote received")
+	case <-time.After(100 * time.Millisecond):
+	}
+	scores := pm.scores.peerScores()
+	if len(scores) != 1 || scores[0].ID != p.id || scores[0].Offenses[offenseInvalidVote.String()] != 1 {
+		t.Errorf("sender not penalized: %v", scores)
+	}
 }
 
 func TestSendVotes(t *testing.T) {
@@ -548,6 +570,9 @@ func TestRecvDKGPrivateShare(t *testing.T) {
 			Signature: []byte("DKGPrivateShare"),
 		},
 	}
+	if err := newTestCoreSigner().SignDKGPrivateShare(&privateShare); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
 
 	if err := p2p.Send(
 		p.app, DKGPrivateShareMsg, &privateShare); err != nil {
@@ -0,0 +1,248 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+const (
+	recordFilePrefix = "consensus-"
+	recordFileSuffix = ".rlp"
+
+	recordFileSize = 64 * 1024 * 1024 // Size a record file is rotated at
+	recordFiles    = 16               // Number of record files kept
+)
+
+// recordedMsgCodes are the codes of the consensus messages recorded.
+var recordedMsgCodes = map[uint64]struct{}{
+	CoreBlockMsg:           {},
+	VoteMsg:                {},
+	AgreementMsg:           {},
+	DKGPrivateShareMsg:     {},
+	DKGPartialSignatureMsg: {},
+	PullBlocksMsg:          {},
+	PullVotesMsg:           {},
+}
+
+// RecordedMsg is a consensus message exchanged with a peer, as recorded.
+type RecordedMsg struct {
+	Time     uint64 // Unix time in nanoseconds
+	Outbound bool   // Sent to the peer rather than received from it
+	Peer     string
+	Code     uint64
+	Payload  rlp.RawValue
+}
+
+// msgRecorder writes the consensus messages exchanged with peers to record
+// files in a directory, rotating them by size.
+type msgRecorder struct {
+	lock     sync.Mutex
+	dir      string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	failed   bool
+}
+
+func newMsgRecorder(dir string, maxSize int64, maxFiles int) (*msgRecorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	r := &msgRecorder{dir: dir, maxSize: maxSize, maxFiles: maxFiles}
+	if err := r.rotate(); err != nil {
+		return nil, err
+	}
+	log.Info("Recording consensus messages", "dir", dir)
+	return r, nil
+}
+
+// recordFileNames returns the record files in dir, oldest first.
+func recordFileNames(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, recordFilePrefix) && strings.HasSuffix(name, recordFileSuffix) {
+			names = append(names, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// rotate starts a new record file, deleting the oldest ones beyond maxFiles.
+func (r *msgRecorder) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s%020d%s", recordFilePrefix, time.Now().UnixNano(), recordFileSuffix)
+	file, err := os.OpenFile(filepath.Join(r.dir, name), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	r.file, r.size = file, 0
+
+	names, err := recordFileNames(r.dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > r.maxFiles {
+		if err := os.Remove(names[0]); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (r *msgRecorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// record writes a message exchanged with peer and returns it with its
+// payload still readable.
+func (r *msgRecorder) record(peer string, outbound bool, msg p2p.Msg) (p2p.Msg, error) {
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+
+	data, err := rlp.EncodeToBytes(&RecordedMsg{
+		Time:     uint64(time.Now().UnixNano()),
+		Outbound: outbound,
+		Peer:     peer,
+		Code:     msg.Code,
+		Payload:  payload,
+	})
+	if err != nil {
+		return msg, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.failed || r.file == nil {
+		return msg, nil
+	}
+	if err := r.write(data); err != nil {
+		log.Error("Failed to record consensus message, recording stopped", "err", err)
+		r.failed = true
+	}
+	return msg, nil
+}
+
+func (r *msgRecorder) write(data []byte) error {
+	if r.size+int64(len(data)) > r.maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	return err
+}
+
+// Close closes the current record file.
+func (r *msgRecorder) Close() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.closeFile()
+}
+
+// recordingMsgReadWriter is a wrapper around a p2p.MsgReadWriter, recording
+// the consensus messages exchanged with a peer.
+type recordingMsgReadWriter struct {
+	p2p.MsgReadWriter
+	recorder *msgRecorder
+	peer     string
+}
+
+func (rw *recordingMsgReadWriter) ReadMsg() (p2p.Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	if _, ok := recordedMsgCodes[msg.Code]; !ok {
+		return msg, nil
+	}
+	return rw.recorder.record(rw.peer, false, msg)
+}
+
+func (rw *recordingMsgReadWriter) WriteMsg(msg p2p.Msg) error {
+	if _, ok := recordedMsgCodes[msg.Code]; ok {
+		var err error
+		if msg, err = rw.recorder.record(rw.peer, true, msg); err != nil {
+			return err
+		}
+	}
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
+
+// ReadRecordedMsgs reads the consensus messages recorded in dir, in the order
+// they were recorded.
+func ReadRecordedMsgs(dir string) ([]*RecordedMsg, error) {
+	names, err := recordFileNames(dir)
+	if err != nil {
+		return nil, err
+	}
+	var msgs []*RecordedMsg
+	for _, name := range names {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		stream := rlp.NewStream(bufio.NewReader(file), 0)
+		for {
+			msg := new(RecordedMsg)
+			if err := stream.Decode(msg); err == io.EOF {
+				break
+			} else if err != nil {
+				// The last message of a file may be truncated by a crash.
+				log.Warn("Truncated consensus record file", "file", name, "err", err)
+				break
+			}
+			msgs = append(msgs, msg)
+		}
+		file.Close()
+	}
+	return msgs, nil
+}
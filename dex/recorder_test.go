@@ -0,0 +1,111 @@
+package dex
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+func TestMsgRecorderReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Rotate on every message, keeping two files.
+	recorder, err := newMsgRecorder(dir, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+	rw := &recordingMsgReadWriter{MsgReadWriter: local, recorder: recorder, peer: "peer"}
+
+	newVote := func() *coreTypes.Vote {
+		return &coreTypes.Vote{
+			VoteHeader: coreTypes.VoteHeader{
+				BlockHash: coreCommon.NewRandomHash(),
+				Position:  coreTypes.Position{Round: 1, Height: 2},
+			},
+		}
+	}
+	vote1, vote2 := newVote(), newVote()
+
+	// Outbound messages are recorded, except non consensus ones.
+	go p2p.Send(rw, VoteMsg, []*coreTypes.Vote{vote1})
+	if err := p2p.ExpectMsg(remote, VoteMsg, []*coreTypes.Vote{vote1}); err != nil {
+		t.Fatal(err)
+	}
+	go p2p.Send(rw, TxMsg, []interface{}{})
+	if err := p2p.ExpectMsg(remote, TxMsg, []interface{}{}); err != nil {
+		t.Fatal(err)
+	}
+	// Inbound messages are recorded and still readable.
+	go p2p.Send(remote, VoteMsg, []*coreTypes.Vote{vote2})
+	msg, err := rw.ReadMsg()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var votes []*coreTypes.Vote
+	if err := msg.Decode(&votes); err != nil || len(votes) != 1 || votes[0].BlockHash != vote2.BlockHash {
+		t.Fatalf("inbound message mismatch: %v, %v", votes, err)
+	}
+	go p2p.Send(remote, PullVotesMsg, coreTypes.Position{Round: 1, Height: 2})
+	if msg, err = rw.ReadMsg(); err != nil {
+		t.Fatal(err)
+	}
+	msg.Discard()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest file was rotated out.
+	names, err := recordFileNames(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 {
+		t.Fatalf("record files mismatch: have %d, want 2", len(names))
+	}
+	msgs, err := ReadRecordedMsgs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("recorded messages mismatch: have %d, want 2", len(msgs))
+	}
+	if msgs[0].Outbound || msgs[0].Code != VoteMsg || msgs[0].Peer != "peer" {
+		t.Errorf("recorded message mismatch: %+v", msgs[0])
+	}
+	if msgs[1].Code != PullVotesMsg {
+		t.Errorf("recorded message mismatch: %+v", msgs[1])
+	}
+
+	// Replaying delivers the inbound vote to the consensus core.
+	network := NewReplayNetwork(msgs)
+	if err := network.Replay(0, nil); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case msg := <-network.ReceiveChan():
+		vote, ok := msg.Payload.(*coreTypes.Vote)
+		if !ok || vote.BlockHash != vote2.BlockHash {
+			t.Errorf("replayed message mismatch: %v", msg.Payload)
+		}
+		network.ReportBadPeerChan() <- msg.PeerID
+		if peers := network.BadPeers(); len(peers) != 1 || peers[0] != "peer" {
+			t.Errorf("bad peers mismatch: %v", peers)
+		}
+	default:
+		t.Fatalf("no message replayed")
+	}
+	if len(network.ReceiveChan()) != 0 {
+		t.Errorf("unexpected replayed messages")
+	}
+}
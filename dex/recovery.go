@@ -18,26 +18,42 @@
 package dex
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dexon-foundation/dexon/accounts/abi"
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/params"
 	"github.com/dexon-foundation/dexon/rlp"
-	"github.com/onrik/ethrpc"
+	"github.com/dexon-foundation/dexon/rpc"
 )
 
 const numConfirmation = 1
 
+const (
+	recoveryRPCTimeout     = 10 * time.Second
+	recoveryMinBackoff     = 5 * time.Second
+	recoveryMaxBackoff     = 5 * time.Minute
+	recoveryMaxVotesPerReq = 100
+	recoveryMaxVotes       = 1 << 16
+	maxSkipBlockStatuses   = 16
+	recoveryStatusTimeout  = 2 * time.Second
+)
+
 const recoveryABI = `
 [
   {
@@ -244,7 +260,10 @@ const recoveryABI = `
 ]
 `
 
-var errAlreadyVoted = errors.New("already voted for recovery")
+var (
+	errAlreadyVoted       = errors.New("already voted for recovery")
+	errNoRecoveryEndpoint = errors.New("no healthy recovery network endpoint")
+)
 
 var abiObject abi.ABI
 
@@ -256,6 +275,85 @@ func init() {
 	}
 }
 
+// recoveryEndpoint is an RPC endpoint of the recovery network. An endpoint
+// failing a request is skipped for a backoff period, which doubles with every
+// consecutive failure.
+type recoveryEndpoint struct {
+	url string
+
+	lock      sync.Mutex
+	client    *rpc.Client
+	failures  int
+	downUntil time.Time
+	lastErr   error
+}
+
+func (e *recoveryEndpoint) healthy(now time.Time) bool {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return !now.Before(e.downUntil)
+}
+
+func (e *recoveryEndpoint) dial(ctx context.Context) (*rpc.Client, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.client == nil {
+		client, err := rpc.DialContext(ctx, e.url)
+		if err != nil {
+			return nil, err
+		}
+		e.client = client
+	}
+	return e.client, nil
+}
+
+// report records the outcome of a request. Errors returned by the remote
+// node do not count as failures of the endpoint.
+func (e *recoveryEndpoint) report(err error) {
+	if _, ok := err.(rpc.Error); ok {
+		err = nil
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if err == nil {
+		e.failures = 0
+		e.downUntil = time.Time{}
+		e.lastErr = nil
+		return
+	}
+	backoff := recoveryMaxBackoff
+	if e.failures < 16 && recoveryMinBackoff<<uint(e.failures) < recoveryMaxBackoff {
+		backoff = recoveryMinBackoff << uint(e.failures)
+	}
+	e.failures++
+	e.downUntil = time.Now().Add(backoff)
+	e.lastErr = err
+	log.Warn("Recovery network endpoint failed", "url", e.url,
+		"failures", e.failures, "backoff", backoff, "err", err)
+}
+
+func (e *recoveryEndpoint) call(result interface{}, method string, args ...interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryRPCTimeout)
+	defer cancel()
+	client, err := e.dial(ctx)
+	if err == nil {
+		err = client.CallContext(ctx, result, method, args...)
+	}
+	e.report(err)
+	return err
+}
+
+func (e *recoveryEndpoint) batchCall(b []rpc.BatchElem) error {
+	ctx, cancel := context.WithTimeout(context.Background(), recoveryRPCTimeout)
+	defer cancel()
+	client, err := e.dial(ctx)
+	if err == nil {
+		err = client.BatchCallContext(ctx, b)
+	}
+	e.report(err)
+	return err
+}
+
 type Recovery struct {
 	gov          *DexconGovernance
 	contract     common.Address
@@ -263,12 +361,26 @@ type Recovery struct {
 	publicKey    string
 	privateKey   *ecdsa.PrivateKey
 	nodeAddress  common.Address
-	client       *ethrpc.EthRPC
+	endpoints    []*recoveryEndpoint
+	quorum       int
+
+	lock       sync.Mutex
+	skipBlocks map[uint64]*SkipBlockStatus
+	refreshing chan struct{} // closed when the running status refresh is done
 }
 
-func NewRecovery(config *params.RecoveryConfig, networkRPC string,
+// NewRecovery creates a Recovery talking to the given endpoints of the
+// recovery network. The votes are only trusted if quorum endpoints agree on
+// them; a non-positive quorum requires a majority of the endpoints.
+func NewRecovery(config *params.RecoveryConfig, networkRPCs []string, quorum int,
 	gov *DexconGovernance, privKey *ecdsa.PrivateKey) *Recovery {
-	client := ethrpc.New(networkRPC)
+	endpoints := make([]*recoveryEndpoint, len(networkRPCs))
+	for i, url := range networkRPCs {
+		endpoints[i] = &recoveryEndpoint{url: url}
+	}
+	if quorum <= 0 || quorum > len(endpoints) {
+		quorum = len(endpoints)/2 + 1
+	}
 	return &Recovery{
 		gov:          gov,
 		contract:     config.Contract,
@@ -276,30 +388,80 @@ func NewRecovery(config *params.RecoveryConfig, networkRPC string,
 		publicKey:    hex.EncodeToString(crypto.FromECDSAPub(&privKey.PublicKey)),
 		privateKey:   privKey,
 		nodeAddress:  crypto.PubkeyToAddress(privKey.PublicKey),
-		client:       client,
+		endpoints:    endpoints,
+		quorum:       quorum,
+		skipBlocks:   make(map[uint64]*SkipBlockStatus),
 	}
 }
 
-func (r *Recovery) callRPC(data []byte, tag string) ([]byte, error) {
-	res, err := r.client.EthCall(ethrpc.T{
-		From: r.nodeAddress.String(),
-		To:   r.contract.String(),
-		Data: "0x" + hex.EncodeToString(data),
-	}, tag)
-	if err != nil {
-		return nil, err
+// healthyEndpoints returns the endpoints not backing off. If every endpoint
+// is backing off, all of them are returned to keep the recovery trying.
+func (r *Recovery) healthyEndpoints() []*recoveryEndpoint {
+	now := time.Now()
+	var endpoints []*recoveryEndpoint
+	for _, e := range r.endpoints {
+		if e.healthy(now) {
+			endpoints = append(endpoints, e)
+		}
+	}
+	if len(endpoints) == 0 {
+		return r.endpoints
 	}
+	return endpoints
+}
 
-	resBytes, err := hex.DecodeString(res[2:])
-	if err != nil {
+// failover runs f on the healthy endpoints in turn until one succeeds.
+func (r *Recovery) failover(f func(e *recoveryEndpoint) error) error {
+	err := errNoRecoveryEndpoint
+	for _, e := range r.healthyEndpoints() {
+		if err = f(e); err == nil || err == errAlreadyVoted {
+			return err
+		}
+		log.Debug("Recovery network request failed", "url", e.url, "err", err)
+	}
+	return err
+}
+
+// forEachEndpoint runs f on the given endpoints concurrently.
+func forEachEndpoint(endpoints []*recoveryEndpoint, f func(i int, e *recoveryEndpoint)) {
+	var wg sync.WaitGroup
+	wg.Add(len(endpoints))
+	for i, e := range endpoints {
+		go func(i int, e *recoveryEndpoint) {
+			defer wg.Done()
+			f(i, e)
+		}(i, e)
+	}
+	wg.Wait()
+}
+
+func (r *Recovery) callArgs(data []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"from": r.nodeAddress,
+		"to":   r.contract,
+		"data": hexutil.Bytes(data),
+	}
+}
+
+func (r *Recovery) callRPC(e *recoveryEndpoint, data []byte, tag string) ([]byte, error) {
+	var res hexutil.Bytes
+	if err := e.call(&res, "eth_call", r.callArgs(data), tag); err != nil {
 		return nil, err
 	}
-	return resBytes, nil
+	return res, nil
 }
 
-func (r *Recovery) genVoteForSkipBlockTx(height uint64) (*types.Transaction, error) {
-	netVersion, err := r.client.NetVersion()
-	if err != nil {
+func (r *Recovery) genVoteForSkipBlockTx(height uint64) (tx *types.Transaction, err error) {
+	err = r.failover(func(e *recoveryEndpoint) error {
+		tx, err = r.genVoteForSkipBlockTxAt(e, height)
+		return err
+	})
+	return tx, err
+}
+
+func (r *Recovery) genVoteForSkipBlockTxAt(e *recoveryEndpoint, height uint64) (*types.Transaction, error) {
+	var netVersion string
+	if err := e.call(&netVersion, "net_version"); err != nil {
 		return nil, err
 	}
 
@@ -313,7 +475,7 @@ func (r *Recovery) genVoteForSkipBlockTx(height uint64) (*types.Transaction, err
 		return nil, err
 	}
 
-	resBytes, err := r.callRPC(data, "latest")
+	resBytes, err := r.callRPC(e, data, "latest")
 	if err != nil {
 		return nil, err
 	}
@@ -334,7 +496,7 @@ func (r *Recovery) genVoteForSkipBlockTx(height uint64) (*types.Transaction, err
 		return nil, err
 	}
 
-	resBytes, err = r.callRPC(data, "latest")
+	resBytes, err = r.callRPC(e, data, "latest")
 	if err != nil {
 		return nil, err
 	}
@@ -350,19 +512,19 @@ func (r *Recovery) genVoteForSkipBlockTx(height uint64) (*types.Transaction, err
 		return nil, err
 	}
 
-	gasPrice, err := r.client.EthGasPrice()
-	if err != nil {
+	var gasPrice hexutil.Big
+	if err := e.call(&gasPrice, "eth_gasPrice"); err != nil {
 		return nil, err
 	}
 
-	nonce, err := r.client.EthGetTransactionCount(r.nodeAddress.String(), "pending")
-	if err != nil {
+	var nonce hexutil.Uint64
+	if err := e.call(&nonce, "eth_getTransactionCount", r.nodeAddress, "pending"); err != nil {
 		return nil, err
 	}
 
 	// Increase gasPrice to 3 times of suggested gas price to make sure it will
 	// be included in time.
-	useGasPrice := new(big.Int).Mul(&gasPrice, big.NewInt(3))
+	useGasPrice := new(big.Int).Mul(gasPrice.ToInt(), big.NewInt(3))
 
 	tx := types.NewTransaction(
 		uint64(nonce),
@@ -376,83 +538,240 @@ func (r *Recovery) genVoteForSkipBlockTx(height uint64) (*types.Transaction, err
 	return types.SignTx(tx, signer, r.privateKey)
 }
 
+// ProposeSkipBlock votes for skipping the block at height.
 func (r *Recovery) ProposeSkipBlock(height uint64) error {
+	_, err := r.proposeSkipBlock(height, false)
+	return err
+}
+
+// ProposeSkipBlockManually votes for skipping the block at height on behalf
+// of the operator, and returns the status of the vote.
+func (r *Recovery) ProposeSkipBlockManually(height uint64) (*SkipBlockStatus, error) {
+	return r.proposeSkipBlock(height, true)
+}
+
+func (r *Recovery) proposeSkipBlock(height uint64, manual bool) (*SkipBlockStatus, error) {
+	status := r.skipBlockStatus(height)
+	hash, err := r.sendVoteForSkipBlock(height)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	status.Manual = status.Manual || manual
+	status.ProposedAt = time.Now()
+	status.Error = ""
+	switch {
+	case err == errAlreadyVoted:
+		if status.TxStatus == "" {
+			status.TxStatus = skipBlockTxAlreadyVoted
+		}
+		err = nil
+	case err != nil:
+		status.Error = err.Error()
+	default:
+		status.TxHash = &hash
+		status.TxStatus = skipBlockTxPending
+	}
+	return status.copy(), err
+}
+
+func (r *Recovery) sendVoteForSkipBlock(height uint64) (common.Hash, error) {
 	notarySet, err := r.gov.NotarySet(r.gov.Round())
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 	if _, ok := notarySet[r.publicKey]; !ok {
-		return errors.New("not in notary set")
+		return common.Hash{}, errors.New("not in notary set")
 	}
 
 	tx, err := r.genVoteForSkipBlockTx(height)
-	if err == errAlreadyVoted {
-		return nil
-	}
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 
 	txData, err := rlp.EncodeToBytes(tx)
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
-	_, err = r.client.EthSendRawTransaction("0x" + hex.EncodeToString(txData))
-	return err
+
+	// Broadcast the vote through every healthy endpoint, it is sent as long
+	// as one of them accepts it.
+	endpoints := r.healthyEndpoints()
+	errs := make([]error, len(endpoints))
+	forEachEndpoint(endpoints, func(i int, e *recoveryEndpoint) {
+		var hash common.Hash
+		errs[i] = e.call(&hash, "eth_sendRawTransaction", hexutil.Bytes(txData))
+	})
+	err = errNoRecoveryEndpoint
+	for i, e := range endpoints {
+		if errs[i] == nil {
+			return tx.Hash(), nil
+		}
+		log.Debug("Failed to send skip block vote", "url", e.url, "err", errs[i])
+		err = errs[i]
+	}
+	return common.Hash{}, err
 }
 
+// Votes returns the number of notary set members voted for skipping the
+// block at height.
 func (r *Recovery) Votes(height uint64) (uint64, error) {
-	data, err := abiObject.Pack("numVotes", new(big.Int).SetUint64(height))
+	status := r.skipBlockStatus(height)
+	voters, err := r.notaryVoters(height)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	status.VotesCheckedAt = time.Now()
 	if err != nil {
+		status.VotesError = err.Error()
 		return 0, err
 	}
+	status.VotesError = ""
+	status.Voters = voters
+	status.Votes = uint64(len(voters))
+	return status.Votes, nil
+}
 
-	bn, err := r.client.EthBlockNumber()
+func (r *Recovery) notaryVoters(height uint64) ([]common.Address, error) {
+	voters, err := r.voters(height)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	snapshotHeight := bn - numConfirmation
-
-	resBytes, err := r.callRPC(data, fmt.Sprintf("0x%x", snapshotHeight))
+	notarySet, err := r.gov.DKGSetNodeKeyAddresses(r.gov.Round())
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	votes := new(big.Int)
-	err = abiObject.Unpack(&votes, "numVotes", resBytes)
-	if err != nil {
-		return 0, err
+	var notaryVoters []common.Address
+	for _, addr := range voters {
+		if _, ok := notarySet[addr]; ok {
+			notaryVoters = append(notaryVoters, addr)
+		}
 	}
+	return notaryVoters, nil
+}
 
-	notarySet, err := r.gov.DKGSetNodeKeyAddresses(r.gov.Round())
+// voters returns the voters for skipping the block at height, as agreed by a
+// quorum of endpoints. The endpoints are read at the same snapshot height,
+// numConfirmation blocks below the highest head reached by a quorum of them.
+func (r *Recovery) voters(height uint64) ([]common.Address, error) {
+	endpoints := r.healthyEndpoints()
+	heads := make([]uint64, len(endpoints))
+	errs := make([]error, len(endpoints))
+	forEachEndpoint(endpoints, func(i int, e *recoveryEndpoint) {
+		var head hexutil.Uint64
+		errs[i] = e.call(&head, "eth_blockNumber")
+		heads[i] = uint64(head)
+	})
+	var reachable []uint64
+	for i := range endpoints {
+		if errs[i] == nil {
+			reachable = append(reachable, heads[i])
+		}
+	}
+	if len(reachable) < r.quorum {
+		return nil, fmt.Errorf("%d of %d recovery network endpoints reachable, quorum is %d",
+			len(reachable), len(r.endpoints), r.quorum)
+	}
+	sort.Slice(reachable, func(i, j int) bool { return reachable[i] > reachable[j] })
+	head := reachable[r.quorum-1]
+	if head < numConfirmation {
+		return nil, fmt.Errorf("recovery network head %d too low", head)
+	}
+	snapshotHeight := head - numConfirmation
+
+	var readers []*recoveryEndpoint
+	for i, e := range endpoints {
+		if errs[i] == nil && heads[i] >= snapshotHeight {
+			readers = append(readers, e)
+		}
+	}
+	results := make([][]common.Address, len(readers))
+	errs = make([]error, len(readers))
+	forEachEndpoint(readers, func(i int, e *recoveryEndpoint) {
+		results[i], errs[i] = r.votersAt(e, height, snapshotHeight)
+	})
+
+	agreed := make(map[string]int)
+	for i, voters := range results {
+		if errs[i] != nil {
+			log.Debug("Failed to read skip block votes", "url", readers[i].url, "err", errs[i])
+			continue
+		}
+		var key bytes.Buffer
+		for _, addr := range voters {
+			key.Write(addr[:])
+		}
+		agreed[key.String()]++
+		if agreed[key.String()] >= r.quorum {
+			return voters, nil
+		}
+	}
+	return nil, fmt.Errorf("no quorum of %d recovery network endpoints agree on votes of height %d",
+		r.quorum, height)
+}
+
+// votersAt reads the voters for skipping the block at height from an
+// endpoint, at the state of snapshotHeight.
+func (r *Recovery) votersAt(e *recoveryEndpoint, height, snapshotHeight uint64) (
+	[]common.Address, error) {
+	tag := hexutil.EncodeUint64(snapshotHeight)
+	data, err := abiObject.Pack("numVotes", new(big.Int).SetUint64(height))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	count := uint64(0)
+	resBytes, err := r.callRPC(e, data, tag)
+	if err != nil {
+		return nil, err
+	}
 
-	for i := uint64(0); i < votes.Uint64(); i++ {
-		data, err = abiObject.Pack(
-			"votes", new(big.Int).SetUint64(height), new(big.Int).SetUint64(i))
-		if err != nil {
-			return 0, err
+	votes := new(big.Int)
+	err = abiObject.Unpack(&votes, "numVotes", resBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !votes.IsUint64() || votes.Uint64() > recoveryMaxVotes {
+		return nil, fmt.Errorf("unexpected number of votes %v", votes)
+	}
+	numVotes := votes.Uint64()
+
+	// Look up the voters in batches.
+	voters := make([]common.Address, 0, numVotes)
+	for start := uint64(0); start < numVotes; start += recoveryMaxVotesPerReq {
+		end := start + recoveryMaxVotesPerReq
+		if end > numVotes {
+			end = numVotes
 		}
-
-		resBytes, err := r.callRPC(data, fmt.Sprintf("0x%x", snapshotHeight))
-		if err != nil {
-			return 0, err
+		var (
+			batch   = make([]rpc.BatchElem, end-start)
+			results = make([]hexutil.Bytes, end-start)
+		)
+		for i := range batch {
+			data, err := abiObject.Pack("votes",
+				new(big.Int).SetUint64(height), new(big.Int).SetUint64(start+uint64(i)))
+			if err != nil {
+				return nil, err
+			}
+			batch[i] = rpc.BatchElem{
+				Method: "eth_call",
+				Args:   []interface{}{r.callArgs(data), tag},
+				Result: &results[i],
+			}
 		}
-
-		var addr common.Address
-		err = abiObject.Unpack(&addr, "votes", resBytes)
-		if err != nil {
-			return 0, err
+		if err := e.batchCall(batch); err != nil {
+			return nil, err
 		}
-
-		if _, ok := notarySet[addr]; ok {
-			count += 1
+		for i, elem := range batch {
+			if elem.Error != nil {
+				return nil, elem.Error
+			}
+			var addr common.Address
+			if err := abiObject.Unpack(&addr, "votes", results[i]); err != nil {
+				return nil, err
+			}
+			voters = append(voters, addr)
 		}
 	}
-	return count, nil
+	return voters, nil
 }
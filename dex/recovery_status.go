@@ -0,0 +1,228 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"sort"
+	"time"
+
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/types"
+)
+
+// Status of the vote transaction for skipping a block.
+const (
+	skipBlockTxPending      = "pending"
+	skipBlockTxConfirmed    = "confirmed"
+	skipBlockTxFailed       = "failed"
+	skipBlockTxAlreadyVoted = "already voted"
+)
+
+// SkipBlockStatus is the local view of the vote for skipping a block.
+type SkipBlockStatus struct {
+	Height     uint64          `json:"height"`
+	Manual     bool            `json:"manual"`     // proposed by the operator
+	ProposedAt time.Time       `json:"proposedAt"` // last attempt to vote
+	Error      string          `json:"error"`      // error of the last attempt to vote
+	TxHash     *common.Hash    `json:"txHash"`
+	TxStatus   string          `json:"txStatus"`
+	TxBlock    *hexutil.Uint64 `json:"txBlock"`
+
+	Votes          uint64           `json:"votes"` // votes of notary set members
+	Voters         []common.Address `json:"voters"`
+	VotesCheckedAt time.Time        `json:"votesCheckedAt"`
+	VotesError     string           `json:"votesError"`
+	Reached        bool             `json:"reached"` // votes exceed the threshold
+}
+
+func (s *SkipBlockStatus) copy() *SkipBlockStatus {
+	c := *s
+	if s.TxHash != nil {
+		hash := *s.TxHash
+		c.TxHash = &hash
+	}
+	if s.TxBlock != nil {
+		number := *s.TxBlock
+		c.TxBlock = &number
+	}
+	c.Voters = append([]common.Address(nil), s.Voters...)
+	return &c
+}
+
+// RecoveryEndpointStatus is the health of a recovery network endpoint.
+type RecoveryEndpointStatus struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures"`
+	DownUntil time.Time `json:"downUntil"`
+	LastError string    `json:"lastError"`
+}
+
+// WatchCatStatus is the state of the watch cat guarding the consensus core
+// sync.
+type WatchCatStatus struct {
+	Running      bool                `json:"running"`
+	StartedAt    time.Time           `json:"startedAt"`
+	LastRecovery *coreTypes.Position `json:"lastRecovery"` // position forced to sync from
+}
+
+// RecoveryStatus is the local view of the recovery.
+type RecoveryStatus struct {
+	WatchCat   *WatchCatStatus           `json:"watchCat"`
+	Quorum     int                       `json:"quorum"`
+	Threshold  uint64                    `json:"threshold"` // votes needed are more than the threshold
+	Endpoints  []*RecoveryEndpointStatus `json:"endpoints"`
+	SkipBlocks []*SkipBlockStatus        `json:"skipBlocks"`
+}
+
+// skipBlockStatus returns the status of skipping the block at height,
+// creating it if needed. Only the statuses of the highest heights are kept.
+func (r *Recovery) skipBlockStatus(height uint64) *SkipBlockStatus {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if status, ok := r.skipBlocks[height]; ok {
+		return status
+	}
+	status := &SkipBlockStatus{Height: height}
+	r.skipBlocks[height] = status
+	if len(r.skipBlocks) > maxSkipBlockStatuses {
+		lowest := height
+		for h := range r.skipBlocks {
+			if h < lowest {
+				lowest = h
+			}
+		}
+		delete(r.skipBlocks, lowest)
+	}
+	return status
+}
+
+// refreshVoteTx updates the status of the vote transaction from the receipt
+// on the recovery network.
+func (r *Recovery) refreshVoteTx(status *SkipBlockStatus) {
+	r.lock.Lock()
+	if status.TxHash == nil || status.TxStatus != skipBlockTxPending {
+		r.lock.Unlock()
+		return
+	}
+	hash := *status.TxHash
+	r.lock.Unlock()
+
+	var receipt *struct {
+		Status      hexutil.Uint64 `json:"status"`
+		BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	}
+	err := r.failover(func(e *recoveryEndpoint) error {
+		return e.call(&receipt, "eth_getTransactionReceipt", hash)
+	})
+	if err != nil || receipt == nil {
+		return
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	status.TxBlock = &receipt.BlockNumber
+	if receipt.Status == hexutil.Uint64(types.ReceiptStatusSuccessful) {
+		status.TxStatus = skipBlockTxConfirmed
+	} else {
+		status.TxStatus = skipBlockTxFailed
+	}
+}
+
+// refreshStatus refreshes the vote transactions and the votes from the
+// recovery network in the background. Only one refresh runs at a time, the
+// returned channel is closed once the running one is done.
+func (r *Recovery) refreshStatus() <-chan struct{} {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.refreshing != nil {
+		return r.refreshing
+	}
+	statuses := make([]*SkipBlockStatus, 0, len(r.skipBlocks))
+	for _, status := range r.skipBlocks {
+		statuses = append(statuses, status)
+	}
+	done := make(chan struct{})
+	r.refreshing = done
+
+	go func() {
+		for _, status := range statuses {
+			r.refreshVoteTx(status)
+			r.Votes(status.Height)
+		}
+		r.lock.Lock()
+		r.refreshing = nil
+		r.lock.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// Status returns the local view of the recovery. The vote transactions and
+// the votes are refreshed from the recovery network first, but slow
+// endpoints are not waited for longer than recoveryStatusTimeout; the
+// statuses they haven't refreshed yet are reported as last checked.
+func (r *Recovery) Status() *RecoveryStatus {
+	threshold := uint64(r.gov.Configuration(r.gov.Round()).NotarySetSize / 2)
+
+	select {
+	case <-r.refreshStatus():
+	case <-time.After(recoveryStatusTimeout):
+	}
+
+	r.lock.Lock()
+	statuses := make([]*SkipBlockStatus, 0, len(r.skipBlocks))
+	for _, status := range r.skipBlocks {
+		statuses = append(statuses, status)
+	}
+	r.lock.Unlock()
+
+	now := time.Now()
+	result := &RecoveryStatus{
+		Quorum:    r.quorum,
+		Threshold: threshold,
+	}
+	for _, e := range r.endpoints {
+		e.lock.Lock()
+		endpoint := &RecoveryEndpointStatus{
+			URL:       e.url,
+			Healthy:   !now.Before(e.downUntil),
+			Failures:  e.failures,
+			DownUntil: e.downUntil,
+		}
+		if e.lastErr != nil {
+			endpoint.LastError = e.lastErr.Error()
+		}
+		e.lock.Unlock()
+		result.Endpoints = append(result.Endpoints, endpoint)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, status := range statuses {
+		status.Reached = status.Votes > threshold
+		result.SkipBlocks = append(result.SkipBlocks, status.copy())
+	}
+	sort.Slice(result.SkipBlocks, func(i, j int) bool {
+		return result.SkipBlocks[i].Height < result.SkipBlocks[j].Height
+	})
+	return result
+}
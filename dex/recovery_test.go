@@ -18,11 +18,20 @@
 package dex
 
 import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rpc"
 )
 
 func TestRecoveryVoteTxGeneration(t *testing.T) {
@@ -35,9 +44,181 @@ func TestRecoveryVoteTxGeneration(t *testing.T) {
 		Contract:     common.HexToAddress("f675c0e9bf4b949f50dcec5b224a70f0361d4680"),
 		Timeout:      30,
 		Confirmation: 1,
-	}, "https://rinkeby.infura.io", nil, key)
+	}, []string{"https://rinkeby.infura.io"}, 0, nil, key)
 	_, err = r.genVoteForSkipBlockTx(0)
 	if err != nil {
 		t.Fatalf("failed to generate voteForSkipBlock tx: %v", err)
 	}
 }
+
+// RecoveryNetworkStub serves the recovery contract reads of a recovery
+// network node.
+type RecoveryNetworkStub struct {
+	head    uint64
+	voters  []common.Address
+	minedTx common.Hash
+}
+
+func (f *RecoveryNetworkStub) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(f.head)
+}
+
+func (f *RecoveryNetworkStub) Call(args map[string]interface{}, tag string) (hexutil.Bytes, error) {
+	data, err := hexutil.Decode(args["data"].(string))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(data, abiObject.Methods["numVotes"].Id()):
+		return abiObject.Methods["numVotes"].Outputs.Pack(big.NewInt(int64(len(f.voters))))
+	case bytes.HasPrefix(data, abiObject.Methods["votes"].Id()):
+		index := new(big.Int).SetBytes(data[4+32 : 4+64]).Uint64()
+		return abiObject.Methods["votes"].Outputs.Pack(f.voters[index])
+	}
+	return nil, errors.New("unexpected call")
+}
+
+func (f *RecoveryNetworkStub) GetTransactionReceipt(hash common.Hash) map[string]interface{} {
+	if hash != f.minedTx {
+		return nil
+	}
+	return map[string]interface{}{
+		"status":      hexutil.Uint64(1),
+		"blockNumber": hexutil.Uint64(f.head),
+	}
+}
+
+func newFakeRecoveryEndpoint(t *testing.T, network *RecoveryNetworkStub) *httptest.Server {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", network); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	return httptest.NewServer(server)
+}
+
+func TestRecoveryVotersQuorum(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+
+	// Enough voters to need several batches.
+	voters := make([]common.Address, 2*recoveryMaxVotesPerReq+1)
+	for i := range voters {
+		voters[i] = common.BigToAddress(big.NewInt(int64(i + 1)))
+	}
+	honest1 := newFakeRecoveryEndpoint(t, &RecoveryNetworkStub{head: 10, voters: voters})
+	defer honest1.Close()
+	honest2 := newFakeRecoveryEndpoint(t, &RecoveryNetworkStub{head: 12, voters: voters})
+	defer honest2.Close()
+	liar := newFakeRecoveryEndpoint(t, &RecoveryNetworkStub{head: 11, voters: voters[:1]})
+	defer liar.Close()
+
+	r := NewRecovery(&params.RecoveryConfig{Confirmation: 1},
+		[]string{liar.URL, honest1.URL, honest2.URL}, 0, nil, key)
+	if r.quorum != 2 {
+		t.Fatalf("quorum mismatch: have %d, want 2", r.quorum)
+	}
+	have, err := r.voters(1)
+	if err != nil {
+		t.Fatalf("failed to read voters: %v", err)
+	}
+	if !reflect.DeepEqual(have, voters) {
+		t.Errorf("voters mismatch: have %d voters, want %d", len(have), len(voters))
+	}
+
+	// Without a quorum of agreeing endpoints the votes are not trusted.
+	honest2.Close()
+	if _, err := r.voters(1); err == nil {
+		t.Errorf("voters returned without quorum")
+	}
+	if r.endpoints[2].healthy(time.Now()) {
+		t.Errorf("unreachable endpoint reported healthy")
+	}
+	if len(r.healthyEndpoints()) != 2 {
+		t.Errorf("healthy endpoints mismatch: have %d, want 2", len(r.healthyEndpoints()))
+	}
+}
+
+func TestRecoverySkipBlockStatus(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	var (
+		minedTx   = common.HexToHash("0x01")
+		pendingTx = common.HexToHash("0x02")
+	)
+	endpoint := newFakeRecoveryEndpoint(t, &RecoveryNetworkStub{head: 10, minedTx: minedTx})
+	defer endpoint.Close()
+	r := NewRecovery(&params.RecoveryConfig{Confirmation: 1}, []string{endpoint.URL}, 0, nil, key)
+
+	mined := r.skipBlockStatus(1)
+	mined.TxHash, mined.TxStatus = &minedTx, skipBlockTxPending
+	pending := r.skipBlockStatus(2)
+	pending.TxHash, pending.TxStatus = &pendingTx, skipBlockTxPending
+	r.refreshVoteTx(mined)
+	r.refreshVoteTx(pending)
+	if mined.TxStatus != skipBlockTxConfirmed || mined.TxBlock == nil || *mined.TxBlock != 10 {
+		t.Errorf("mined vote tx status mismatch: %s", mined.TxStatus)
+	}
+	if pending.TxStatus != skipBlockTxPending || pending.TxBlock != nil {
+		t.Errorf("pending vote tx status mismatch: %s", pending.TxStatus)
+	}
+
+	// Only the statuses of the highest heights are kept.
+	for height := uint64(3); height <= maxSkipBlockStatuses+1; height++ {
+		r.skipBlockStatus(height)
+	}
+	if len(r.skipBlocks) != maxSkipBlockStatuses {
+		t.Errorf("status count mismatch: have %d, want %d", len(r.skipBlocks), maxSkipBlockStatuses)
+	}
+	if _, ok := r.skipBlocks[1]; ok {
+		t.Errorf("status of lowest height not dropped")
+	}
+}
+
+func TestRecoveryStatusRefresh(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate keypair: %v", err)
+	}
+	// The endpoint stalls until released.
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &RecoveryNetworkStub{}); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	release := make(chan struct{})
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		server.ServeHTTP(w, req)
+	}))
+	defer endpoint.Close()
+	r := NewRecovery(&params.RecoveryConfig{Confirmation: 1}, []string{endpoint.URL}, 0, nil, key)
+
+	txHash := common.HexToHash("0x01")
+	status := r.skipBlockStatus(1)
+	status.TxHash, status.TxStatus = &txHash, skipBlockTxPending
+
+	done := r.refreshStatus()
+	if r.refreshStatus() != done {
+		t.Errorf("concurrent status refreshes started")
+	}
+	select {
+	case <-done:
+		t.Fatalf("status refreshed from a stalled endpoint")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("status refresh not done")
+	}
+	if status.VotesCheckedAt.IsZero() {
+		t.Errorf("votes not checked")
+	}
+	if r.refreshStatus() == done {
+		t.Errorf("finished status refresh reused")
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/types"
+	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+var _ core.Network = (*ReplayNetwork)(nil)
+
+// ReplayNetwork is a fake DexconNetwork which feeds the consensus core with
+// the inbound consensus messages of a record, in the recorded order. The
+// messages the core sends are collected instead of being sent.
+type ReplayNetwork struct {
+	msgs              []*RecordedMsg
+	receiveCh         chan types.Msg
+	reportBadPeerChan chan interface{}
+
+	lock sync.Mutex
+	sent []interface{}
+}
+
+// NewReplayNetwork creates a network replaying the recorded messages.
+func NewReplayNetwork(msgs []*RecordedMsg) *ReplayNetwork {
+	return &ReplayNetwork{
+		msgs:              msgs,
+		receiveCh:         make(chan types.Msg, 1024),
+		reportBadPeerChan: make(chan interface{}, 1024),
+	}
+}
+
+// DecodeRecordedMsg decodes the payload of a recorded message into the
+// messages passed to the consensus core, as the protocol manager does. Pull
+// requests are answered by the protocol manager, so they result in no
+// message.
+func DecodeRecordedMsg(msg *RecordedMsg) ([]interface{}, error) {
+	var payloads []interface{}
+	switch msg.Code {
+	case CoreBlockMsg:
+		var blocks []*types.Block
+		if err := rlp.DecodeBytes(msg.Payload, &blocks); err != nil {
+			return nil, err
+		}
+		for _, block := range blocks {
+			payloads = append(payloads, block)
+		}
+	case VoteMsg:
+		var votes []*types.Vote
+		if err := rlp.DecodeBytes(msg.Payload, &votes); err != nil {
+			return nil, err
+		}
+		for _, vote := range votes {
+			payloads = append(payloads, vote)
+		}
+	case AgreementMsg:
+		var agreement types.AgreementResult
+		if err := rlp.DecodeBytes(msg.Payload, &agreement); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, &agreement)
+	case DKGPrivateShareMsg:
+		var ps dkgTypes.PrivateShare
+		if err := rlp.DecodeBytes(msg.Payload, &ps); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, &ps)
+	case DKGPartialSignatureMsg:
+		var psig dkgTypes.PartialSignature
+		if err := rlp.DecodeBytes(msg.Payload, &psig); err != nil {
+			return nil, err
+		}
+		payloads = append(payloads, &psig)
+	case PullBlocksMsg, PullVotesMsg:
+	default:
+		return nil, fmt.Errorf("unexpected message code %#x", msg.Code)
+	}
+	return payloads, nil
+}
+
+// msgOffense returns the offense of a peer sending an invalid message.
+func msgOffense(payload interface{}) peerOffense {
+	switch payload.(type) {
+	case *types.Block:
+		return offenseInvalidBlock
+	case *types.Vote:
+		return offenseInvalidVote
+	case *types.AgreementResult:
+		return offenseInvalidAgreement
+	case *dkgTypes.PrivateShare, *dkgTypes.PartialSignature:
+		return offenseInvalidDKG
+	}
+	return offenseProtocol
+}
+
+// Replay delivers the inbound messages to the receive channel. With a
+// positive speed, the delay between two messages is the recorded one divided
+// by speed, otherwise messages are delivered as fast as they are received.
+// Replay returns once all messages are delivered, or when stop is closed.
+func (n *ReplayNetwork) Replay(speed float64, stop <-chan struct{}) error {
+	var last uint64
+	for i, msg := range n.msgs {
+		if msg.Outbound {
+			continue
+		}
+		payloads, err := DecodeRecordedMsg(msg)
+		if err != nil {
+			return fmt.Errorf("message %d: %v", i, err)
+		}
+		if speed > 0 && last != 0 && msg.Time > last {
+			delay := time.Duration(float64(msg.Time-last) / speed)
+			select {
+			case <-time.After(delay):
+			case <-stop:
+				return nil
+			}
+		}
+		last = msg.Time
+		for _, payload := range payloads {
+			select {
+			case n.receiveCh <- types.Msg{
+				PeerID:  coreMsgSource{msg.Peer, msgOffense(payload)},
+				Payload: payload,
+			}:
+			case <-stop:
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// Sent returns the messages the consensus core sent so far.
+func (n *ReplayNetwork) Sent() []interface{} {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return append([]interface{}(nil), n.sent...)
+}
+
+// BadPeers returns the peers reported by the consensus core since the last
+// call.
+func (n *ReplayNetwork) BadPeers() []string {
+	var peers []string
+	for {
+		select {
+		case report := <-n.reportBadPeerChan:
+			switch r := report.(type) {
+			case coreMsgSource:
+				peers = append(peers, r.id)
+			case string:
+				peers = append(peers, r)
+			}
+		default:
+			return peers
+		}
+	}
+}
+
+func (n *ReplayNetwork) send(msg interface{}) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.sent = append(n.sent, msg)
+}
+
+// PullBlocks implements core.Network.
+func (n *ReplayNetwork) PullBlocks(hashes coreCommon.Hashes) {
+	n.send(hashes)
+}
+
+// PullVotes implements core.Network.
+func (n *ReplayNetwork) PullVotes(pos types.Position) {
+	n.send(pos)
+}
+
+// BroadcastVote implements core.Network.
+func (n *ReplayNetwork) BroadcastVote(vote *types.Vote) {
+	n.send(vote)
+}
+
+// BroadcastBlock implements core.Network.
+func (n *ReplayNetwork) BroadcastBlock(block *types.Block) {
+	n.send(block)
+}
+
+// BroadcastAgreementResult implements core.Network.
+func (n *ReplayNetwork) BroadcastAgreementResult(result *types.AgreementResult) {
+	n.send(result)
+}
+
+// SendDKGPrivateShare implements core.Network.
+func (n *ReplayNetwork) SendDKGPrivateShare(
+	pub crypto.PublicKey, prvShare *dkgTypes.PrivateShare) {
+	n.send(prvShare)
+}
+
+// BroadcastDKGPrivateShare implements core.Network.
+func (n *ReplayNetwork) BroadcastDKGPrivateShare(prvShare *dkgTypes.PrivateShare) {
+	n.send(prvShare)
+}
+
+// BroadcastDKGPartialSignature implements core.Network.
+func (n *ReplayNetwork) BroadcastDKGPartialSignature(psig *dkgTypes.PartialSignature) {
+	n.send(psig)
+}
+
+// ReceiveChan implements core.Network.
+func (n *ReplayNetwork) ReceiveChan() <-chan types.Msg {
+	return n.receiveCh
+}
+
+// ReportBadPeerChan implements core.Network.
+func (n *ReplayNetwork) ReportBadPeerChan() chan<- interface{} {
+	return n.reportBadPeerChan
+}
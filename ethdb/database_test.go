@@ -212,3 +212,24 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_ReadOnly(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("put failed: %v", err)
+	}
+	db.Close()
+
+	ro, err := ethdb.NewReadOnlyLDBDatabase(db.Path(), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+	defer ro.Close()
+	if value, err := ro.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Fatalf("get returned wrong result, got %q (%v) expected %q", value, err, "value")
+	}
+	if err := ro.Put([]byte("key"), []byte("other")); err == nil {
+		t.Fatalf("put into read-only database succeeded")
+	}
+}
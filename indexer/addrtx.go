@@ -0,0 +1,144 @@
+package indexer
+
+import (
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// AddressTxIndexerName is the Config.Builtin name of AddressTxIndexer.
+const AddressTxIndexerName = "txbyaddr"
+
+func init() {
+	RegisterBuiltin(AddressTxIndexerName, func(bc ReadOnlyBlockChain, c Config) Indexer {
+		return NewAddressTxIndexer(bc, c.Database)
+	})
+}
+
+// AddressTxEntry is a positional reference to a transaction sent from, sent
+// to or creating an address.
+type AddressTxEntry struct {
+	BlockNumber uint64
+	TxIndex     uint64
+	TxHash      common.Hash
+}
+
+// AddressTxIndexer indexes the canonical transactions of every address in
+// block order. Blocks already in the database when the indexer is enabled are
+// backfilled on start, new ones are indexed as they are inserted.
+type AddressTxIndexer struct {
+	*chainFollower
+
+	bc    ReadOnlyBlockChain
+	store listStore
+}
+
+// NewAddressTxIndexer creates an address transaction indexer storing its data
+// in the given database.
+func NewAddressTxIndexer(bc ReadOnlyBlockChain, db ethdb.Database) *AddressTxIndexer {
+	table := ethdb.NewTable(db, string(rawdb.AddressTxIndexPrefix))
+	idx := &AddressTxIndexer{
+		bc:    bc,
+		store: listStore{db: table},
+	}
+	idx.chainFollower = newChainFollower(AddressTxIndexerName, bc, table, idx)
+	return idx
+}
+
+func (idx *AddressTxIndexer) processBlock(
+	batch ethdb.Batch, block *types.Block, receipts types.Receipts) error {
+	var (
+		appender = newListAppender(idx.store, batch)
+		signer   = types.MakeSigner(idx.bc.Config(), block.Number())
+	)
+	for i, tx := range block.Transactions() {
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return err
+		}
+		addrs := []common.Address{from}
+		if to := tx.To(); to != nil {
+			addrs = append(addrs, *to)
+		} else if i < len(receipts) {
+			addrs = append(addrs, receipts[i].ContractAddress)
+		}
+
+		entry, err := rlp.EncodeToBytes(&AddressTxEntry{
+			BlockNumber: block.NumberU64(),
+			TxIndex:     uint64(i),
+			TxHash:      tx.Hash(),
+		})
+		if err != nil {
+			return err
+		}
+		for j, addr := range addrs {
+			// Self transfers are indexed only once.
+			if j > 0 && addr == from {
+				continue
+			}
+			if err := appender.append(addr.Bytes(), entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TxCount returns the number of indexed transactions of the address.
+func (idx *AddressTxIndexer) TxCount(addr common.Address) uint64 {
+	return idx.store.length(addr.Bytes())
+}
+
+// TxEntry returns the i-th indexed transaction of the address, in block order.
+func (idx *AddressTxIndexer) TxEntry(addr common.Address, i uint64) (*AddressTxEntry, error) {
+	data, err := idx.store.item(addr.Bytes(), i)
+	if err != nil {
+		return nil, err
+	}
+	entry := new(AddressTxEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// TxRange returns the half-open range [start, end) of the indexed
+// transactions of the address within blocks [fromBlock, toBlock].
+func (idx *AddressTxIndexer) TxRange(addr common.Address,
+	fromBlock, toBlock uint64) (start, end uint64, err error) {
+	// search returns the first entry located at a block not lower than number.
+	search := func(number uint64) (uint64, error) {
+		return idx.store.search(addr.Bytes(), func(item []byte) (bool, error) {
+			entry := new(AddressTxEntry)
+			if err := rlp.DecodeBytes(item, entry); err != nil {
+				return false, err
+			}
+			return entry.BlockNumber >= number, nil
+		})
+	}
+	if start, err = search(fromBlock); err != nil {
+		return 0, 0, err
+	}
+	if toBlock == ^uint64(0) {
+		return start, idx.TxCount(addr), nil
+	}
+	if end, err = search(toBlock + 1); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// APIs implements APIProvider.
+func (idx *AddressTxIndexer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "dex",
+			Version:   "1.0",
+			Service:   NewPublicAddressTxAPI(idx),
+			Public:    true,
+		},
+	}
+}
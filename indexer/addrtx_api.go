@@ -0,0 +1,118 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/internal/ethapi"
+)
+
+const (
+	// defaultAddressTxLimit is the page size used when the query sets none.
+	defaultAddressTxLimit = 100
+
+	// maxAddressTxLimit is the largest page size served by a single query.
+	maxAddressTxLimit = 1000
+)
+
+// AddressTxQuery selects a page of the transactions of an address. Block
+// bounds are inclusive and default to the whole indexed chain, Offset and
+// Limit page through the matching transactions in block order, or from the
+// newest one if Reverse is set.
+type AddressTxQuery struct {
+	FromBlock *hexutil.Uint64 `json:"fromBlock"`
+	ToBlock   *hexutil.Uint64 `json:"toBlock"`
+	Offset    hexutil.Uint64  `json:"offset"`
+	Limit     hexutil.Uint64  `json:"limit"`
+	Reverse   bool            `json:"reverse"`
+}
+
+// AddressTxPage is a page of transactions of an address.
+type AddressTxPage struct {
+	Transactions []*ethapi.RPCTransaction `json:"transactions"`
+
+	// Total is the number of transactions matching the block range.
+	Total hexutil.Uint64 `json:"total"`
+
+	// IndexedBlocks is the number of blocks covered by the index, which may
+	// lag behind the chain head while backfilling.
+	IndexedBlocks hexutil.Uint64 `json:"indexedBlocks"`
+}
+
+// PublicAddressTxAPI provides access to the address transaction index.
+type PublicAddressTxAPI struct {
+	idx *AddressTxIndexer
+}
+
+// NewPublicAddressTxAPI creates a new address transaction API.
+func NewPublicAddressTxAPI(idx *AddressTxIndexer) *PublicAddressTxAPI {
+	return &PublicAddressTxAPI{idx: idx}
+}
+
+// GetTransactionsByAddress returns the transactions sent from, sent to or
+// creating the given address.
+func (api *PublicAddressTxAPI) GetTransactionsByAddress(ctx context.Context,
+	addr common.Address, query *AddressTxQuery) (*AddressTxPage, error) {
+	if query == nil {
+		query = new(AddressTxQuery)
+	}
+	fromBlock, toBlock := uint64(0), ^uint64(0)
+	if query.FromBlock != nil {
+		fromBlock = uint64(*query.FromBlock)
+	}
+	if query.ToBlock != nil {
+		toBlock = uint64(*query.ToBlock)
+	}
+	if fromBlock > toBlock {
+		return nil, fmt.Errorf("invalid block range: %d > %d", fromBlock, toBlock)
+	}
+	limit := uint64(query.Limit)
+	if limit == 0 {
+		limit = defaultAddressTxLimit
+	}
+	if limit > maxAddressTxLimit {
+		return nil, fmt.Errorf("limit %d exceeds maximum %d", limit, maxAddressTxLimit)
+	}
+
+	indexed := api.idx.Next()
+	start, end, err := api.idx.TxRange(addr, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	page := &AddressTxPage{
+		Transactions:  []*ethapi.RPCTransaction{},
+		Total:         hexutil.Uint64(end - start),
+		IndexedBlocks: hexutil.Uint64(indexed),
+	}
+	offset := uint64(query.Offset)
+	if offset >= end-start {
+		return page, nil
+	}
+	n := end - start - offset
+	if n > limit {
+		n = limit
+	}
+	for i := uint64(0); i < n; i++ {
+		pos := start + offset + i
+		if query.Reverse {
+			pos = end - 1 - offset - i
+		}
+		entry, err := api.idx.TxEntry(addr, pos)
+		if err != nil {
+			return nil, err
+		}
+		block := api.idx.bc.GetBlockByNumber(entry.BlockNumber)
+		if block == nil {
+			return nil, fmt.Errorf("block %d not found", entry.BlockNumber)
+		}
+		tx := ethapi.NewRPCTransactionFromBlockIndex(block, entry.TxIndex)
+		if tx == nil || tx.Hash != entry.TxHash {
+			return nil, fmt.Errorf("transaction %x not found in block %d",
+				entry.TxHash, entry.BlockNumber)
+		}
+		page.Transactions = append(page.Transactions, tx)
+	}
+	return page, nil
+}
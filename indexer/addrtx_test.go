@@ -0,0 +1,243 @@
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/event"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddress = crypto.PubkeyToAddress(testKey.PublicKey)
+)
+
+// testChain is a minimal ReadOnlyBlockChain backed by a slice of blocks.
+type testChain struct {
+	ReadOnlyBlockChain
+
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	feed     event.Feed
+}
+
+func newTestChain() *testChain {
+	genesis := types.NewBlock(&types.Header{Number: big.NewInt(0)}, nil, nil, nil)
+	return &testChain{
+		blocks:   []*types.Block{genesis},
+		receipts: make(map[common.Hash]types.Receipts),
+	}
+}
+
+// addBlock appends a block with the given transactions, each sent by testKey.
+func (c *testChain) addBlock(t *testing.T, tos ...*common.Address) *types.Block {
+	var (
+		number   = big.NewInt(int64(len(c.blocks)))
+		signer   = types.MakeSigner(c.Config(), number)
+		txs      []*types.Transaction
+		receipts types.Receipts
+	)
+	for _, to := range tos {
+		var tx *types.Transaction
+		nonce := uint64(len(c.blocks)*10 + len(txs))
+		if to == nil {
+			tx = types.NewContractCreation(nonce, big.NewInt(0), params.TxGas, nil, nil)
+		} else {
+			tx = types.NewTransaction(nonce, *to, big.NewInt(1), params.TxGas, nil, nil)
+		}
+		tx, err := types.SignTx(tx, signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		if to == nil {
+			receipt.ContractAddress = crypto.CreateAddress(testAddress, nonce)
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	header := &types.Header{
+		Number:     number,
+		ParentHash: c.blocks[len(c.blocks)-1].Hash(),
+	}
+	block := types.NewBlock(header, txs, nil, receipts)
+	c.blocks = append(c.blocks, block)
+	c.receipts[block.Hash()] = receipts
+	return block
+}
+
+func (c *testChain) Config() *params.ChainConfig { return params.TestnetChainConfig }
+
+func (c *testChain) CurrentBlock() *types.Block { return c.blocks[len(c.blocks)-1] }
+
+func (c *testChain) GetBlockByNumber(number uint64) *types.Block {
+	if number >= uint64(len(c.blocks)) {
+		return nil
+	}
+	return c.blocks[number]
+}
+
+func (c *testChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	return c.receipts[hash]
+}
+
+func (c *testChain) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+func waitIndexed(t *testing.T, f *chainFollower, next uint64) {
+	for i := 0; i < 100; i++ {
+		if f.Next() >= next {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("indexer did not reach block %d, at %d", next, f.Next())
+}
+
+func TestAddressTxIndexer(t *testing.T) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		chain = newTestChain()
+		addr1 = common.HexToAddress("0x1")
+		addr2 = common.HexToAddress("0x2")
+	)
+	chain.addBlock(t, &addr1, &addr2)
+	chain.addBlock(t, &testAddress, nil)
+
+	idx := NewAddressTxIndexer(chain, db)
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to start indexer: %v", err)
+	}
+	waitIndexed(t, idx.chainFollower, 3)
+
+	// New blocks are picked up through chain events.
+	block := chain.addBlock(t, &addr1)
+	chain.feed.Send(core.ChainEvent{Block: block, Hash: block.Hash()})
+	waitIndexed(t, idx.chainFollower, 4)
+	idx.Stop()
+
+	if n := idx.TxCount(testAddress); n != 5 {
+		t.Errorf("sender tx count mismatch: have %d, want 5", n)
+	}
+	if n := idx.TxCount(addr1); n != 2 {
+		t.Errorf("receiver tx count mismatch: have %d, want 2", n)
+	}
+	created := crypto.CreateAddress(testAddress, 21)
+	if n := idx.TxCount(created); n != 1 {
+		t.Errorf("created contract tx count mismatch: have %d, want 1", n)
+	}
+	entry, err := idx.TxEntry(addr1, 1)
+	if err != nil {
+		t.Fatalf("failed to read entry: %v", err)
+	}
+	if entry.BlockNumber != 3 || entry.TxIndex != 0 ||
+		entry.TxHash != chain.blocks[3].Transactions()[0].Hash() {
+		t.Errorf("entry mismatch: %+v", entry)
+	}
+
+	// A restarted indexer resumes from its stored progress.
+	chain.addBlock(t, &addr2)
+	idx = NewAddressTxIndexer(chain, db)
+	if next := idx.Next(); next != 4 {
+		t.Fatalf("progress mismatch: have %d, want 4", next)
+	}
+	idx.Start()
+	waitIndexed(t, idx.chainFollower, 5)
+	idx.Stop()
+	if n := idx.TxCount(addr2); n != 2 {
+		t.Errorf("receiver tx count mismatch: have %d, want 2", n)
+	}
+}
+
+func TestGetTransactionsByAddress(t *testing.T) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		chain = newTestChain()
+		addr  = common.HexToAddress("0x1")
+	)
+	// Block i holds i transactions to addr.
+	for i := 1; i <= 4; i++ {
+		tos := make([]*common.Address, i)
+		for j := range tos {
+			tos[j] = &addr
+		}
+		chain.addBlock(t, tos...)
+	}
+	idx := NewAddressTxIndexer(chain, db)
+	idx.Start()
+	waitIndexed(t, idx.chainFollower, 5)
+	idx.Stop()
+
+	start, end, err := idx.TxRange(addr, 2, 3)
+	if err != nil {
+		t.Fatalf("failed to query range: %v", err)
+	}
+	if start != 1 || end != 6 {
+		t.Errorf("range mismatch: have [%d, %d), want [1, 6)", start, end)
+	}
+
+	api := NewPublicAddressTxAPI(idx)
+	from, to := hexutil.Uint64(2), hexutil.Uint64(4)
+	tests := []struct {
+		query  *AddressTxQuery
+		total  uint64
+		blocks []uint64
+	}{
+		{nil, 10, []uint64{1, 2, 2, 3, 3, 3, 4, 4, 4, 4}},
+		{&AddressTxQuery{Limit: 2, Offset: 1}, 10, []uint64{2, 2}},
+		{&AddressTxQuery{Limit: 2, Reverse: true}, 10, []uint64{4, 4}},
+		{&AddressTxQuery{FromBlock: &from, Limit: 3}, 9, []uint64{2, 2, 3}},
+		{&AddressTxQuery{ToBlock: &from, Offset: 1, Reverse: true}, 3, []uint64{2, 1}},
+		{&AddressTxQuery{FromBlock: &to, Offset: 10}, 4, nil},
+	}
+	for i, tt := range tests {
+		page, err := api.GetTransactionsByAddress(context.Background(), addr, tt.query)
+		if err != nil {
+			t.Fatalf("test %d: query failed: %v", i, err)
+		}
+		if uint64(page.Total) != tt.total {
+			t.Errorf("test %d: total mismatch: have %d, want %d", i, page.Total, tt.total)
+		}
+		if len(page.Transactions) != len(tt.blocks) {
+			t.Fatalf("test %d: page size mismatch: have %d, want %d",
+				i, len(page.Transactions), len(tt.blocks))
+		}
+		for j, tx := range page.Transactions {
+			if tx.BlockNumber.ToInt().Uint64() != tt.blocks[j] {
+				t.Errorf("test %d: tx %d block mismatch: have %v, want %d",
+					i, j, tx.BlockNumber, tt.blocks[j])
+			}
+		}
+	}
+	if _, err := api.GetTransactionsByAddress(context.Background(), addr,
+		&AddressTxQuery{FromBlock: &to, ToBlock: &from}); err == nil {
+		t.Errorf("inverted block range accepted")
+	}
+}
+
+func TestNewIndexerFromConfig(t *testing.T) {
+	chain := newTestChain()
+	db := ethdb.NewMemDatabase()
+
+	if _, err := NewIndexerFromConfig(chain, Config{Builtin: []string{AddressTxIndexerName, "unknown"}, Database: db}); err == nil {
+		t.Fatalf("unknown built-in indexer accepted")
+	}
+	idx, err := NewIndexerFromConfig(chain, Config{Builtin: []string{AddressTxIndexerName}, Database: db})
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	// Stopping an indexer which never started is a no-op.
+	if err := idx.Stop(); err != nil {
+		t.Fatalf("failed to stop indexer: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// builtinIndexers holds the constructors of indexers compiled into the
+// binary, keyed by the name used in Config.Builtin.
+var builtinIndexers = map[string]NewIndexerFunc{}
+
+// RegisterBuiltin makes an indexer selectable by name through Config.Builtin.
+// It is meant to be called from init functions and panics on duplicate names.
+func RegisterBuiltin(name string, fn NewIndexerFunc) {
+	if _, exists := builtinIndexers[name]; exists {
+		panic(fmt.Errorf("built-in indexer %s already registered", name))
+	}
+	builtinIndexers[name] = fn
+}
+
+// BuiltinNames returns the sorted names of all registered built-in indexers.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtinIndexers))
+	for name := range builtinIndexers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// multiIndexer runs several indexers as a single one.
+type multiIndexer []Indexer
+
+// Start starts all indexers in order, stopping the already started ones if
+// any of them fails.
+func (m multiIndexer) Start() error {
+	for i, idx := range m {
+		if err := idx.Start(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				m[j].Stop()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops all indexers in reverse order and returns the first error.
+func (m multiIndexer) Stop() (err error) {
+	for i := len(m) - 1; i >= 0; i-- {
+		if stopErr := m[i].Stop(); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}
+	return
+}
+
+// APIs collects the RPC APIs of all indexers implementing APIProvider.
+func (m multiIndexer) APIs() []rpc.API {
+	var apis []rpc.API
+	for _, idx := range m {
+		if provider, ok := idx.(APIProvider); ok {
+			apis = append(apis, provider.APIs()...)
+		}
+	}
+	return apis
+}
@@ -1,10 +1,12 @@
 package indexer
 
 import (
+	"fmt"
 	"plugin"
 
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/dex/downloader"
+	"github.com/dexon-foundation/dexon/ethdb"
 )
 
 // Config is data sources related configs struct.
@@ -18,6 +20,12 @@ type Config struct {
 	// PluginFlags for construction if needed.
 	PluginFlags string
 
+	// Builtin lists the names of indexers compiled into the binary to run.
+	Builtin []string
+
+	// Database for built-in indexers to store their data, set by dex/backend.
+	Database ethdb.Database `toml:"-"`
+
 	// The genesis block from dex.Config
 	Genesis *core.Genesis
 
@@ -27,22 +35,37 @@ type Config struct {
 }
 
 // NewIndexerFromConfig initialize exporter according to given config.
-func NewIndexerFromConfig(bc ReadOnlyBlockChain, c Config) (idx Indexer) {
-	if c.Plugin == "" {
-		// default
-		return
+func NewIndexerFromConfig(bc ReadOnlyBlockChain, c Config) (Indexer, error) {
+	var indexers []Indexer
+	for _, name := range c.Builtin {
+		fn, ok := builtinIndexers[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown built-in indexer: %s", name)
+		}
+		indexers = append(indexers, fn(bc, c))
 	}
 
-	plug, err := plugin.Open(c.Plugin)
-	if err != nil {
-		panic(err)
-	}
+	if c.Plugin != "" {
+		plug, err := plugin.Open(c.Plugin)
+		if err != nil {
+			return nil, err
+		}
 
-	symbol, err := plug.Lookup(NewIndexerFuncName)
-	if err != nil {
-		panic(err)
+		symbol, err := plug.Lookup(NewIndexerFuncName)
+		if err != nil {
+			return nil, err
+		}
+
+		indexers = append(indexers, symbol.(NewIndexerFunc)(bc, c))
 	}
 
-	idx = symbol.(NewIndexerFunc)(bc, c)
-	return
+	switch len(indexers) {
+	case 0:
+		// default
+		return nil, nil
+	case 1:
+		return indexers[0], nil
+	default:
+		return multiIndexer(indexers), nil
+	}
 }
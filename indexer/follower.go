@@ -0,0 +1,238 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/event"
+	"github.com/dexon-foundation/dexon/log"
+)
+
+// chainEventChanSize is the size of channel listening to ChainEvent.
+const chainEventChanSize = 1024
+
+// progressKey tracks the number of the next block to be processed.
+var progressKey = []byte("Progress")
+
+// blockProcessor is implemented by built-in indexers which build their data
+// block by block. All writes go through the given batch, which is committed
+// together with the indexing progress.
+type blockProcessor interface {
+	processBlock(batch ethdb.Batch, block *types.Block, receipts types.Receipts) error
+}
+
+// chainFollower feeds the canonical chain into a blockProcessor. On start it
+// catches up from the last processed block to the current head, then keeps
+// following ChainEvents posted by insertDexonChain and ProcessBlock.
+type chainFollower struct {
+	name string
+	bc   ReadOnlyBlockChain
+	db   ethdb.Database
+	proc blockProcessor
+
+	mu   sync.Mutex
+	next uint64
+
+	chainCh  chan core.ChainEvent
+	chainSub event.Subscription
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newChainFollower(name string, bc ReadOnlyBlockChain, db ethdb.Database,
+	proc blockProcessor) *chainFollower {
+	f := &chainFollower{
+		name: name,
+		bc:   bc,
+		db:   db,
+		proc: proc,
+		quit: make(chan struct{}),
+	}
+	if data, _ := db.Get(progressKey); len(data) == 8 {
+		f.next = binary.BigEndian.Uint64(data)
+	}
+	return f
+}
+
+// Start subscribes to chain events and launches the indexing loop.
+func (f *chainFollower) Start() error {
+	f.chainCh = make(chan core.ChainEvent, chainEventChanSize)
+	f.chainSub = f.bc.SubscribeChainEvent(f.chainCh)
+
+	f.wg.Add(1)
+	go f.loop()
+	log.Info("Built-in indexer started", "name", f.name, "next", f.Next())
+	return nil
+}
+
+// Stop terminates the indexing loop and waits for it to exit.
+func (f *chainFollower) Stop() error {
+	if f.chainSub == nil {
+		return nil
+	}
+	f.chainSub.Unsubscribe()
+	close(f.quit)
+	f.wg.Wait()
+	log.Info("Built-in indexer stopped", "name", f.name, "next", f.Next())
+	return nil
+}
+
+// Next returns the number of the next block to be indexed, which is also the
+// number of blocks covered by the index.
+func (f *chainFollower) Next() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.next
+}
+
+func (f *chainFollower) loop() {
+	defer f.wg.Done()
+
+	f.catchUp(f.bc.CurrentBlock().NumberU64())
+	for {
+		select {
+		case ev := <-f.chainCh:
+			f.catchUp(ev.Block.NumberU64())
+		case <-f.chainSub.Err():
+			return
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// catchUp indexes all canonical blocks up to the given height.
+func (f *chainFollower) catchUp(head uint64) {
+	for number := f.Next(); number <= head; number++ {
+		select {
+		case <-f.quit:
+			return
+		default:
+		}
+		block := f.bc.GetBlockByNumber(number)
+		if block == nil {
+			log.Warn("Indexer block not found", "name", f.name, "number", number)
+			return
+		}
+		if err := f.index(block); err != nil {
+			log.Error("Failed to index block", "name", f.name,
+				"number", number, "err", err)
+			return
+		}
+	}
+}
+
+func (f *chainFollower) index(block *types.Block) error {
+	batch := f.db.NewBatch()
+	receipts := f.bc.GetReceiptsByHash(block.Hash())
+	if err := f.proc.processBlock(batch, block, receipts); err != nil {
+		return err
+	}
+	next := block.NumberU64() + 1
+	if err := batch.Put(progressKey, encodeUint64(next)); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.next = next
+	f.mu.Unlock()
+	return nil
+}
+
+// listStore keeps append-only lists of encoded items grouped by key. Each list
+// is stored as a length entry and one entry per item, so any range of a list
+// can be read without iterating the database.
+type listStore struct {
+	db ethdb.Database
+}
+
+var (
+	listLengthPrefix = []byte("n") // listLengthPrefix + key -> length (uint64 big endian)
+	listItemPrefix   = []byte("e") // listItemPrefix + key + index (uint64 big endian) -> item
+)
+
+func listLengthKey(key []byte) []byte {
+	return append(append([]byte{}, listLengthPrefix...), key...)
+}
+
+func listItemKey(key []byte, index uint64) []byte {
+	return append(append(append([]byte{}, listItemPrefix...), key...),
+		encodeUint64(index)...)
+}
+
+// length returns the number of items in the list.
+func (s listStore) length(key []byte) uint64 {
+	data, _ := s.db.Get(listLengthKey(key))
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// item returns the encoded item at the given index of the list.
+func (s listStore) item(key []byte, index uint64) ([]byte, error) {
+	return s.db.Get(listItemKey(key, index))
+}
+
+// search returns the smallest index of the list for which f returns true,
+// assuming f is monotonic over the items, or the list length if there is none.
+func (s listStore) search(key []byte, f func(item []byte) (bool, error)) (uint64, error) {
+	var err error
+	index := sort.Search(int(s.length(key)), func(i int) bool {
+		if err != nil {
+			return true
+		}
+		var item []byte
+		if item, err = s.item(key, uint64(i)); err != nil {
+			return true
+		}
+		found, ferr := f(item)
+		if ferr != nil {
+			err = ferr
+			return true
+		}
+		return found
+	})
+	return uint64(index), err
+}
+
+// listAppender appends items to lists of a listStore through a batch. It
+// tracks the pending lengths since a batch can not be read back.
+type listAppender struct {
+	store   listStore
+	batch   ethdb.Batch
+	lengths map[string]uint64
+}
+
+func newListAppender(store listStore, batch ethdb.Batch) *listAppender {
+	return &listAppender{
+		store:   store,
+		batch:   batch,
+		lengths: make(map[string]uint64),
+	}
+}
+
+func (a *listAppender) append(key []byte, item []byte) error {
+	length, ok := a.lengths[string(key)]
+	if !ok {
+		length = a.store.length(key)
+	}
+	if err := a.batch.Put(listItemKey(key, length), item); err != nil {
+		return err
+	}
+	a.lengths[string(key)] = length + 1
+	return a.batch.Put(listLengthKey(key), encodeUint64(length+1))
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
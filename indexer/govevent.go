@@ -0,0 +1,185 @@
+package indexer
+
+import (
+	"github.com/dexon-foundation/dexon/accounts/abi"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// GovEventIndexerName is the Config.Builtin name of GovEventIndexer.
+const GovEventIndexerName = "govevents"
+
+func init() {
+	RegisterBuiltin(GovEventIndexerName, func(bc ReadOnlyBlockChain, c Config) Indexer {
+		return NewGovEventIndexer(bc, c.Database)
+	})
+}
+
+// GovEventEntry is a log emitted by the governance contract together with its
+// position in the chain.
+type GovEventEntry struct {
+	BlockNumber uint64
+	BlockHash   common.Hash
+	Round       uint64
+	TxHash      common.Hash
+	TxIndex     uint64
+	LogIndex    uint64
+	Topics      []common.Hash
+	Data        []byte
+}
+
+// nodeEventIDs is the set of governance events whose first indexed argument
+// is a node address.
+var nodeEventIDs = make(map[common.Hash]struct{})
+
+func init() {
+	for _, event := range vm.GovernanceABI.Events {
+		if len(event.Inputs) > 0 && event.Inputs[0].Indexed &&
+			event.Inputs[0].Type.T == abi.AddressTy {
+			nodeEventIDs[event.Id()] = struct{}{}
+		}
+	}
+}
+
+// GovEventIndexer indexes the logs of the governance contract, both in chain
+// order and grouped by the node address they refer to.
+type GovEventIndexer struct {
+	*chainFollower
+
+	store listStore
+}
+
+// NewGovEventIndexer creates a governance event indexer storing its data in
+// the given database.
+func NewGovEventIndexer(bc ReadOnlyBlockChain, db ethdb.Database) *GovEventIndexer {
+	table := ethdb.NewTable(db, string(rawdb.GovEventIndexPrefix))
+	idx := &GovEventIndexer{
+		store: listStore{db: table},
+	}
+	idx.chainFollower = newChainFollower(GovEventIndexerName, bc, table, idx)
+	return idx
+}
+
+func (idx *GovEventIndexer) processBlock(
+	batch ethdb.Batch, block *types.Block, receipts types.Receipts) error {
+	var (
+		appender = newListAppender(idx.store, batch)
+		txs      = block.Transactions()
+		logIndex uint64
+	)
+	for i, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			logIndex++
+			if l.Address != vm.GovernanceContractAddress || len(l.Topics) == 0 {
+				continue
+			}
+			entry := &GovEventEntry{
+				BlockNumber: block.NumberU64(),
+				BlockHash:   block.Hash(),
+				Round:       block.Round(),
+				TxIndex:     uint64(i),
+				LogIndex:    logIndex - 1,
+				Topics:      l.Topics,
+				Data:        l.Data,
+			}
+			if i < len(txs) {
+				entry.TxHash = txs[i].Hash()
+			}
+			data, err := rlp.EncodeToBytes(entry)
+			if err != nil {
+				return err
+			}
+			if err := appender.append(nil, data); err != nil {
+				return err
+			}
+			if _, ok := nodeEventIDs[l.Topics[0]]; ok && len(l.Topics) > 1 {
+				addr := common.BytesToAddress(l.Topics[1].Bytes())
+				if err := appender.append(addr.Bytes(), data); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// EventCount returns the number of indexed governance events.
+func (idx *GovEventIndexer) EventCount() uint64 {
+	return idx.store.length(nil)
+}
+
+// Event returns the i-th indexed governance event in chain order.
+func (idx *GovEventIndexer) Event(i uint64) (*GovEventEntry, error) {
+	return idx.decode(idx.store.item(nil, i))
+}
+
+// NodeEventCount returns the number of indexed governance events referring to
+// the node address.
+func (idx *GovEventIndexer) NodeEventCount(addr common.Address) uint64 {
+	return idx.store.length(addr.Bytes())
+}
+
+// NodeEvent returns the i-th indexed governance event referring to the node
+// address in chain order.
+func (idx *GovEventIndexer) NodeEvent(addr common.Address, i uint64) (*GovEventEntry, error) {
+	return idx.decode(idx.store.item(addr.Bytes(), i))
+}
+
+// EventRange returns the half-open range [start, end) of the indexed events
+// within rounds [fromRound, toRound]. Events of all nodes are covered if node
+// is nil, otherwise only those referring to the node address.
+func (idx *GovEventIndexer) EventRange(node *common.Address,
+	fromRound, toRound uint64) (start, end uint64, err error) {
+	var key []byte
+	if node != nil {
+		key = node.Bytes()
+	}
+	// search returns the first event emitted in a round not lower than round.
+	search := func(round uint64) (uint64, error) {
+		return idx.store.search(key, func(item []byte) (bool, error) {
+			entry, err := idx.decode(item, nil)
+			if err != nil {
+				return false, err
+			}
+			return entry.Round >= round, nil
+		})
+	}
+	if start, err = search(fromRound); err != nil {
+		return 0, 0, err
+	}
+	if toRound == ^uint64(0) {
+		return start, idx.store.length(key), nil
+	}
+	if end, err = search(toRound + 1); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+// APIs implements APIProvider.
+func (idx *GovEventIndexer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "governance",
+			Version:   "1.0",
+			Service:   NewPublicGovEventAPI(idx),
+			Public:    true,
+		},
+	}
+}
+
+func (idx *GovEventIndexer) decode(data []byte, err error) (*GovEventEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	entry := new(GovEventEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
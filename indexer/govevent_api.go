@@ -0,0 +1,178 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+)
+
+const (
+	// defaultGovEventLimit is the page size used when the query sets none.
+	defaultGovEventLimit = 100
+
+	// maxGovEventLimit is the largest page size served by a single query.
+	maxGovEventLimit = 1000
+)
+
+// GovEventQuery selects a page of governance events. Round bounds are
+// inclusive and default to all indexed rounds, Offset and Limit page through
+// the matching events in chain order, or from the newest one if Reverse is
+// set.
+type GovEventQuery struct {
+	Node      *common.Address `json:"node"`
+	FromRound *hexutil.Uint64 `json:"fromRound"`
+	ToRound   *hexutil.Uint64 `json:"toRound"`
+	Offset    hexutil.Uint64  `json:"offset"`
+	Limit     hexutil.Uint64  `json:"limit"`
+	Reverse   bool            `json:"reverse"`
+}
+
+// GovEvent is a decoded governance contract event.
+type GovEvent struct {
+	Event string `json:"event"`
+
+	// Node is the node address the event refers to, if any.
+	Node *common.Address `json:"node,omitempty"`
+
+	// Args holds the event arguments keyed by their ABI names.
+	Args map[string]interface{} `json:"args"`
+
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	Round            hexutil.Uint64 `json:"round"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	LogIndex         hexutil.Uint64 `json:"logIndex"`
+}
+
+// GovEventPage is a page of governance events.
+type GovEventPage struct {
+	Events []*GovEvent `json:"events"`
+
+	// Total is the number of events matching the node and round range.
+	Total hexutil.Uint64 `json:"total"`
+
+	// IndexedBlocks is the number of blocks covered by the index, which may
+	// lag behind the chain head while backfilling.
+	IndexedBlocks hexutil.Uint64 `json:"indexedBlocks"`
+}
+
+// PublicGovEventAPI provides access to the governance event index.
+type PublicGovEventAPI struct {
+	idx *GovEventIndexer
+}
+
+// NewPublicGovEventAPI creates a new governance event API.
+func NewPublicGovEventAPI(idx *GovEventIndexer) *PublicGovEventAPI {
+	return &PublicGovEventAPI{idx: idx}
+}
+
+// GetEvents returns the decoded governance contract events matching the query.
+func (api *PublicGovEventAPI) GetEvents(ctx context.Context,
+	query *GovEventQuery) (*GovEventPage, error) {
+	if query == nil {
+		query = new(GovEventQuery)
+	}
+	fromRound, toRound := uint64(0), ^uint64(0)
+	if query.FromRound != nil {
+		fromRound = uint64(*query.FromRound)
+	}
+	if query.ToRound != nil {
+		toRound = uint64(*query.ToRound)
+	}
+	if fromRound > toRound {
+		return nil, fmt.Errorf("invalid round range: %d > %d", fromRound, toRound)
+	}
+	limit := uint64(query.Limit)
+	if limit == 0 {
+		limit = defaultGovEventLimit
+	}
+	if limit > maxGovEventLimit {
+		return nil, fmt.Errorf("limit %d exceeds maximum %d", limit, maxGovEventLimit)
+	}
+
+	indexed := api.idx.Next()
+	start, end, err := api.idx.EventRange(query.Node, fromRound, toRound)
+	if err != nil {
+		return nil, err
+	}
+	page := &GovEventPage{
+		Events:        []*GovEvent{},
+		Total:         hexutil.Uint64(end - start),
+		IndexedBlocks: hexutil.Uint64(indexed),
+	}
+	offset := uint64(query.Offset)
+	if offset >= end-start {
+		return page, nil
+	}
+	n := end - start - offset
+	if n > limit {
+		n = limit
+	}
+	for i := uint64(0); i < n; i++ {
+		pos := start + offset + i
+		if query.Reverse {
+			pos = end - 1 - offset - i
+		}
+		var entry *GovEventEntry
+		if query.Node != nil {
+			entry, err = api.idx.NodeEvent(*query.Node, pos)
+		} else {
+			entry, err = api.idx.Event(pos)
+		}
+		if err != nil {
+			return nil, err
+		}
+		event, err := decodeGovEvent(entry)
+		if err != nil {
+			return nil, err
+		}
+		page.Events = append(page.Events, event)
+	}
+	return page, nil
+}
+
+// decodeGovEvent decodes an indexed governance log with the governance ABI.
+func decodeGovEvent(entry *GovEventEntry) (*GovEvent, error) {
+	event, args, err := vm.GovernanceABI.UnpackLog(&types.Log{
+		Address: vm.GovernanceContractAddress,
+		Topics:  entry.Topics,
+		Data:    entry.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode log %d of block %d: %v",
+			entry.LogIndex, entry.BlockNumber, err)
+	}
+	result := &GovEvent{
+		Event:            event.Name,
+		Args:             make(map[string]interface{}, len(args)),
+		BlockNumber:      hexutil.Uint64(entry.BlockNumber),
+		BlockHash:        entry.BlockHash,
+		Round:            hexutil.Uint64(entry.Round),
+		TransactionHash:  entry.TxHash,
+		TransactionIndex: hexutil.Uint64(entry.TxIndex),
+		LogIndex:         hexutil.Uint64(entry.LogIndex),
+	}
+	if _, ok := nodeEventIDs[entry.Topics[0]]; ok {
+		node := args[event.Inputs[0].Name].(common.Address)
+		result.Node = &node
+	}
+	for name, value := range args {
+		switch v := value.(type) {
+		case *big.Int:
+			result.Args[name] = (*hexutil.Big)(v)
+		case []byte:
+			result.Args[name] = hexutil.Bytes(v)
+		case [32]byte:
+			result.Args[name] = common.Hash(v)
+		default:
+			result.Args[name] = v
+		}
+	}
+	return result, nil
+}
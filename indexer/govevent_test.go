@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+// addGovBlock appends a block of the given round holding one governance
+// transaction per given log.
+func (c *testChain) addGovBlock(t *testing.T, round uint64, logs ...*types.Log) {
+	var (
+		number   = big.NewInt(int64(len(c.blocks)))
+		signer   = types.MakeSigner(c.Config(), number)
+		txs      []*types.Transaction
+		receipts types.Receipts
+	)
+	for _, l := range logs {
+		tx := types.NewTransaction(uint64(len(c.blocks)*10+len(txs)),
+			vm.GovernanceContractAddress, big.NewInt(0), params.TxGas, nil, nil)
+		tx, err := types.SignTx(tx, signer, testKey)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		receipt := types.NewReceipt(nil, false, 0)
+		receipt.Logs = []*types.Log{
+			{Address: common.HexToAddress("0x1234")}, // not a governance log
+			l,
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	header := &types.Header{
+		Number:     number,
+		ParentHash: c.blocks[len(c.blocks)-1].Hash(),
+		Round:      round,
+	}
+	block := types.NewBlock(header, txs, nil, receipts)
+	c.blocks = append(c.blocks, block)
+	c.receipts[block.Hash()] = receipts
+}
+
+func stakedLog(node common.Address, amount int64) *types.Log {
+	return &types.Log{
+		Address: vm.GovernanceContractAddress,
+		Topics:  []common.Hash{vm.GovernanceABI.Events["Staked"].Id(), node.Hash()},
+		Data:    common.BigToHash(big.NewInt(amount)).Bytes(),
+	}
+}
+
+func dkgResetLog(round, height int64) *types.Log {
+	return &types.Log{
+		Address: vm.GovernanceContractAddress,
+		Topics: []common.Hash{vm.GovernanceABI.Events["DKGReset"].Id(),
+			common.BigToHash(big.NewInt(round))},
+		Data: common.BigToHash(big.NewInt(height)).Bytes(),
+	}
+}
+
+func TestGovEventIndexer(t *testing.T) {
+	var (
+		db    = ethdb.NewMemDatabase()
+		chain = newTestChain()
+		node1 = common.HexToAddress("0x1")
+		node2 = common.HexToAddress("0x2")
+	)
+	chain.addGovBlock(t, 0, stakedLog(node1, 1), stakedLog(node2, 2))
+	chain.addGovBlock(t, 1, dkgResetLog(2, 3))
+	chain.addGovBlock(t, 2, stakedLog(node1, 4))
+	chain.addGovBlock(t, 3, stakedLog(node2, 5), stakedLog(node1, 6))
+
+	idx := NewGovEventIndexer(chain, db)
+	idx.Start()
+	waitIndexed(t, idx.chainFollower, 5)
+	idx.Stop()
+
+	if n := idx.EventCount(); n != 6 {
+		t.Fatalf("event count mismatch: have %d, want 6", n)
+	}
+	if n := idx.NodeEventCount(node1); n != 3 {
+		t.Fatalf("node event count mismatch: have %d, want 3", n)
+	}
+
+	api := NewPublicGovEventAPI(idx)
+	round1, round2 := hexutil.Uint64(1), hexutil.Uint64(2)
+	page, err := api.GetEvents(context.Background(), &GovEventQuery{
+		FromRound: &round1,
+		ToRound:   &round2,
+	})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if page.Total != 2 || len(page.Events) != 2 {
+		t.Fatalf("page size mismatch: total %d, events %d", page.Total, len(page.Events))
+	}
+	reset := page.Events[0]
+	if reset.Event != "DKGReset" || reset.Node != nil || reset.Round != 1 ||
+		reset.LogIndex != 1 || reset.TransactionHash != chain.blocks[2].Transactions()[0].Hash() {
+		t.Errorf("DKGReset event mismatch: %+v", reset)
+	}
+	if height := reset.Args["BlockHeight"].(*hexutil.Big).ToInt(); height.Int64() != 3 {
+		t.Errorf("DKGReset height mismatch: have %v, want 3", height)
+	}
+
+	page, err = api.GetEvents(context.Background(), &GovEventQuery{
+		Node:    &node1,
+		Reverse: true,
+	})
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	var amounts []int64
+	for _, event := range page.Events {
+		if event.Event != "Staked" || event.Node == nil || *event.Node != node1 {
+			t.Errorf("node event mismatch: %+v", event)
+		}
+		amounts = append(amounts, event.Args["Amount"].(*hexutil.Big).ToInt().Int64())
+	}
+	if len(amounts) != 3 || amounts[0] != 6 || amounts[1] != 4 || amounts[2] != 1 {
+		t.Errorf("node event amounts mismatch: have %v, want [6 4 1]", amounts)
+	}
+}
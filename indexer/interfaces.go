@@ -1,5 +1,9 @@
 package indexer
 
+import (
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
 // NewIndexerFuncName plugin looks up name.
 var NewIndexerFuncName = "NewIndexer"
 
@@ -17,3 +21,9 @@ type Indexer interface {
 	// terminating.
 	Stop() error
 }
+
+// APIProvider is implemented by indexers serving their data over RPC. The
+// APIs are registered by dex.Dexon along with its own ones.
+type APIProvider interface {
+	APIs() []rpc.API
+}
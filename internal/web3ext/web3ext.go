@@ -24,6 +24,8 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"ethash":     Ethash_JS,
 	"debug":      Debug_JS,
+	"governance": Governance_JS,
+	"dex":        Dex_JS,
 	"eth":        Eth_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
@@ -207,6 +209,33 @@ web3._extend({
 			name: 'stopProposing',
 			call: 'admin_stopProposing'
 		}),
+		new web3._extend.Method({
+			name: 'recoveryStatus',
+			call: 'admin_recoveryStatus'
+		}),
+		new web3._extend.Method({
+			name: 'proposeSkipBlock',
+			call: 'admin_proposeSkipBlock',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'peerBans',
+			call: 'admin_peerBans'
+		}),
+		new web3._extend.Method({
+			name: 'peerScores',
+			call: 'admin_peerScores'
+		}),
+		new web3._extend.Method({
+			name: 'unban',
+			call: 'admin_unban',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'clearBans',
+			call: 'admin_clearBans'
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -442,6 +471,11 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'verifyBlock',
+			call: 'debug_verifyBlock',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -464,6 +498,20 @@ web3._extend({
 });
 `
 
+const Dex_JS = `
+web3._extend({
+	property: 'dex',
+	methods: [
+		new web3._extend.Method({
+			name: 'getTransactionsByAddress',
+			call: 'dex_getTransactionsByAddress',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+	]
+});
+`
+
 const Eth_JS = `
 web3._extend({
 	property: 'eth',
@@ -534,6 +582,63 @@ web3._extend({
 });
 `
 
+const Governance_JS = `
+web3._extend({
+	property: 'governance',
+	methods: [
+		new web3._extend.Method({
+			name: 'getEvents',
+			call: 'governance_getEvents',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getRoundHeight',
+			call: 'governance_getRoundHeight',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'getConfiguration',
+			call: 'governance_getConfiguration',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getNodes',
+			call: 'governance_getNodes',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getCRS',
+			call: 'governance_getCRS',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getNotarySet',
+			call: 'governance_getNotarySet',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getDKGStatus',
+			call: 'governance_getDKGStatus',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'round',
+			getter: 'governance_getRound',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+	]
+});
+`
+
 const Miner_JS = `
 web3._extend({
 	property: 'miner',
@@ -20,14 +20,18 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/core/rawdb"
 	"github.com/dexon-foundation/dexon/core/state"
@@ -44,6 +48,10 @@ var (
 	blockCacheLimit = 256
 )
 
+// errNoGovState is returned if the governance state at a header is not
+// available locally.
+var errNoGovState = errors.New("governance state not available")
+
 // LightChain represents a canonical chain that by default only handles block
 // headers, downloading block bodies and receipts on demand through an ODR
 // interface. It only does header validation during chain insertion.
@@ -72,6 +80,8 @@ type LightChain struct {
 	wg            sync.WaitGroup
 
 	engine consensus.Engine
+
+	roundHeights sync.Map // round -> height of the first header of the round
 }
 
 // NewLightChain returns a fully initialised light chain using information
@@ -171,6 +181,7 @@ func (bc *LightChain) SetHead(head uint64) {
 
 	bc.hc.SetHead(head, nil)
 	bc.loadLastState()
+	bc.purgeRoundHeights()
 }
 
 // GasLimit returns the gas limit of the current HEAD block.
@@ -326,6 +337,7 @@ func (self *LightChain) Rollback(chain []common.Hash) {
 			self.hc.SetCurrentHeader(self.GetHeader(head.ParentHash, head.Number.Uint64()-1))
 		}
 	}
+	self.purgeRoundHeights()
 }
 
 // postChainEvents iterates over the events generated by a chain insertion and
@@ -394,6 +406,85 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// InsertDexonHeaderChain attempts to insert the given DEXON header chain in
+// to the local chain. The headers are verified against the round
+// configurations and node sets of the governance state, and their randomness
+// against the threshold signature of the notary set of their round. The
+// governance states shipped with the headers at round heights are stored, so
+// that the chain follows the governance state across rounds.
+//
+// The les protocol doesn't sync DEXON headers with their governance states
+// yet, so light clients don't insert headers through here so far.
+func (self *LightChain) InsertDexonHeaderChain(chain []*types.HeaderWithGovState,
+	gov dexcon.GovernanceStateFetcher, verifierCache *dexCore.TSigVerifierCache) (int, error) {
+	if len(chain) == 0 {
+		return 0, nil
+	}
+	start := time.Now()
+	if i, err := self.hc.ValidateDexonHeaderChain(chain, gov, verifierCache, self, true); err != nil {
+		return i, err
+	}
+
+	// Make sure only one thread manipulates the chain at once
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.wg.Add(1)
+	defer self.wg.Done()
+
+	var events []interface{}
+	whFunc := func(header *types.HeaderWithGovState) error {
+		self.mu.Lock()
+		defer self.mu.Unlock()
+
+		status, err := self.hc.WriteDexonHeader(header)
+
+		switch status {
+		case core.CanonStatTy:
+			log.Debug("Inserted new header", "number", header.Number, "hash", header.Hash())
+			events = append(events, core.ChainEvent{Block: types.NewBlockWithHeader(header.Header), Hash: header.Hash()})
+
+		case core.SideStatTy:
+			log.Debug("Inserted forked header", "number", header.Number, "hash", header.Hash())
+			events = append(events, core.ChainSideEvent{Block: types.NewBlockWithHeader(header.Header)})
+		}
+		return err
+	}
+	i, err := self.hc.InsertDexonHeaderChain(chain, whFunc, start)
+	self.postChainEvents(events)
+	return i, err
+}
+
+// ValidateWitnessData checks that the block witnessed at height is the
+// canonical one.
+func (self *LightChain) ValidateWitnessData(height uint64, blockHash common.Hash) error {
+	header := self.GetHeaderByNumber(height)
+	if header == nil || header.Hash() != blockHash {
+		return consensus.ErrWitnessMismatch
+	}
+	return nil
+}
+
+// GetGovStateByHash retrieves the governance state at the header of the given
+// hash. Light chains only keep the governance states shipped with the headers
+// at round heights.
+func (self *LightChain) GetGovStateByHash(hash common.Hash) (*types.GovState, error) {
+	if govState := rawdb.ReadGovState(self.chainDb, hash); govState != nil {
+		return govState, nil
+	}
+	return nil, errNoGovState
+}
+
+// GetGovStateByNumber retrieves the governance state at the canonical header
+// of the given number.
+func (self *LightChain) GetGovStateByNumber(number uint64) (*types.GovState, error) {
+	header := self.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, errors.New("header not found")
+	}
+	return self.GetGovStateByHash(header.Hash())
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
@@ -540,7 +631,34 @@ func (self *LightChain) StateAt(root common.Hash) (*state.StateDB, error) {
 	return nil, nil
 }
 
-// GetRoundHeight returns the height of a given round.
+// GetRoundHeight returns the height of the first canonical header of a given
+// round.
 func (self *LightChain) GetRoundHeight(round uint64) (uint64, bool) {
-	return 0, false
+	if height, ok := self.roundHeights.Load(round); ok {
+		return height.(uint64), true
+	}
+	// Rounds never decrease along the chain, search the first header of the
+	// round.
+	head := self.CurrentHeader().Number.Uint64()
+	number := uint64(sort.Search(int(head)+1, func(i int) bool {
+		header := self.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Round >= round
+	}))
+	header := self.GetHeaderByNumber(number)
+	if header == nil || header.Round != round {
+		return 0, false
+	}
+	// The first header of the current round may still be rolled back.
+	if round < self.CurrentHeader().Round {
+		self.roundHeights.Store(round, number)
+	}
+	return number, true
+}
+
+// purgeRoundHeights forgets the cached round heights after a rewind.
+func (self *LightChain) purgeRoundHeights() {
+	self.roundHeights.Range(func(round, _ interface{}) bool {
+		self.roundHeights.Delete(round)
+		return true
+	})
 }
@@ -18,16 +18,29 @@ package light
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"math/big"
 	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	coreTypesDKG "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
 	"github.com/dexon-foundation/dexon/consensus/ethash"
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/dex/downloader"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rlp"
 )
 
 // So we can deterministically seed different blockchains
@@ -352,3 +365,155 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Make sure the light chain can be synced by the DEXON downloader.
+var _ downloader.LightChain = (*LightChain)(nil)
+
+type testGovStateFetcher struct {
+	statedb *state.StateDB
+}
+
+func (g *testGovStateFetcher) GetStateForConfigAtRound(_ uint64) *vm.GovernanceState {
+	return &vm.GovernanceState{StateDB: g.statedb}
+}
+
+func (g *testGovStateFetcher) DKGSetNodeKeyAddresses(_ uint64) (map[common.Address]struct{}, error) {
+	return make(map[common.Address]struct{}), nil
+}
+
+// testDKGState serves the DKG results of a node set to a TSigVerifierCache.
+type testDKGState struct {
+	nodes *dexcon.NodeSet
+}
+
+func (s *testDKGState) Configuration(round uint64) *coreTypes.Config {
+	return &coreTypes.Config{NotarySetSize: uint32(len(s.nodes.Nodes(round)))}
+}
+
+func (s *testDKGState) DKGComplaints(round uint64) []*coreTypesDKG.Complaint {
+	return nil
+}
+
+func (s *testDKGState) DKGMasterPublicKeys(round uint64) []*coreTypesDKG.MasterPublicKey {
+	var mpks []*coreTypesDKG.MasterPublicKey
+	for _, node := range s.nodes.Nodes(round) {
+		mpks = append(mpks, node.MasterPublicKey(round))
+	}
+	return mpks
+}
+
+func (s *testDKGState) IsDKGFinal(round uint64) bool {
+	return true
+}
+
+// makeDexonHeaderChain creates a chain of empty DEXON headers rooted at parent,
+// one per round, with their randomness signed by nodes.
+func makeDexonHeaderChain(parent *types.Header, rounds []uint64, gasLimit uint64,
+	nodes *dexcon.NodeSet) []*types.HeaderWithGovState {
+	var headers []*types.HeaderWithGovState
+	for _, round := range rounds {
+		number := parent.Number.Uint64() + 1
+		coreBlock := coreTypes.Block{
+			Position:  coreTypes.Position{Round: round, Height: number},
+			Timestamp: time.Unix(int64(parent.Time/1000)+1, 0).UTC(),
+			Hash:      coreCommon.NewRandomHash(),
+		}
+		coreBlock.Randomness = nodes.Randomness(round, common.Hash(coreBlock.Hash))
+		meta, err := rlp.EncodeToBytes(&coreBlock)
+		if err != nil {
+			panic(err)
+		}
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Root:       parent.Root,
+			Difficulty: big.NewInt(1),
+			Number:     new(big.Int).SetUint64(number),
+			GasLimit:   gasLimit,
+			Time:       uint64(coreBlock.Timestamp.UnixNano() / 1000000),
+			Round:      round,
+			Randomness: coreBlock.Randomness,
+			DexconMeta: meta,
+		}
+		headers = append(headers, &types.HeaderWithGovState{Header: header})
+		parent = header
+	}
+	return headers
+}
+
+// Tests that DEXON headers are verified against the threshold signature of
+// their round, and that the light chain follows the governance state across
+// rounds.
+func TestInsertDexonHeaderChain(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	gspec := core.Genesis{Config: params.TestnetChainConfig}
+	genesis := gspec.MustCommit(db)
+	lc, err := NewLightChain(&dummyOdr{db: db, indexerConfig: TestClientIndexerConfig}, gspec.Config, dexcon.New())
+	if err != nil {
+		t.Fatalf("failed to create light chain: %v", err)
+	}
+	statedb, err := state.New(genesis.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	gov := &testGovStateFetcher{statedb: statedb}
+	gasLimit := gov.GetStateForConfigAtRound(0).Configuration().BlockGasLimit
+
+	var keys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, key)
+	}
+	signer := types.NewEIP155Signer(gspec.Config.ChainID)
+	nodes := dexcon.NewNodeSet(0, []byte("crs"), signer, keys)
+	nodes.RunDKG(1, 3)
+	verifierCache := dexCore.NewTSigVerifierCache(&testDKGState{nodes: nodes}, 5)
+
+	headers := makeDexonHeaderChain(genesis.Header(), []uint64{0, 0, 0, 1, 1, 1}, gasLimit, nodes)
+	govState, err := state.GetGovState(statedb, headers[3].Header, vm.GovernanceContractAddress)
+	if err != nil {
+		t.Fatalf("failed to get governance state: %v", err)
+	}
+	headers[3].GovState = govState
+
+	// Headers with forged randomness are rejected.
+	forged := makeDexonHeaderChain(genesis.Header(), []uint64{0, 1}, gasLimit, nodes)
+	var coreBlock coreTypes.Block
+	if err := rlp.DecodeBytes(forged[1].DexconMeta, &coreBlock); err != nil {
+		t.Fatalf("failed to decode dexcon meta: %v", err)
+	}
+	coreBlock.Randomness = nodes.Randomness(1, common.Hash{1})
+	forged[1].Randomness = coreBlock.Randomness
+	if forged[1].DexconMeta, err = rlp.EncodeToBytes(&coreBlock); err != nil {
+		t.Fatalf("failed to encode dexcon meta: %v", err)
+	}
+	if _, err := lc.InsertDexonHeaderChain(forged, gov, verifierCache); err == nil {
+		t.Fatalf("headers with forged randomness inserted")
+	}
+	if number := lc.CurrentHeader().Number.Uint64(); number != 0 {
+		t.Fatalf("head number mismatch: have %d, want 0", number)
+	}
+
+	if _, err := lc.InsertDexonHeaderChain(headers, gov, verifierCache); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	if head := lc.CurrentHeader().Hash(); head != headers[5].Hash() {
+		t.Fatalf("head hash mismatch: have %x, want %x", head, headers[5].Hash())
+	}
+
+	for round, want := range []uint64{0, 4} {
+		if height, ok := lc.GetRoundHeight(uint64(round)); !ok || height != want {
+			t.Errorf("round %d height mismatch: have %d (%v), want %d", round, height, ok, want)
+		}
+	}
+	if _, ok := lc.GetRoundHeight(2); ok {
+		t.Errorf("height of future round found")
+	}
+
+	if s, err := lc.GetGovStateByNumber(4); err != nil || s.BlockHash != headers[3].Hash() {
+		t.Errorf("shipped governance state mismatch: %v", err)
+	}
+	// Governance states not shipped with the headers are not available.
+	if _, err := lc.GetGovStateByNumber(1); err != errNoGovState {
+		t.Errorf("unshipped governance state error mismatch: have %v, want %v", err, errNoGovState)
+	}
+}
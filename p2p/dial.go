@@ -17,10 +17,12 @@
 package p2p
 
 import (
+	"bytes"
 	"container/heap"
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/dexon-foundation/dexon/log"
@@ -115,6 +117,7 @@ type dialTask struct {
 	dest         *enode.Node
 	lastResolved time.Time
 	resolveDelay time.Duration
+	priority     int // direct dials of higher priority are launched first
 }
 
 // discoverTask runs discovery table operations.
@@ -164,8 +167,8 @@ func (s *dialstate) removeStatic(n *enode.Node) {
 	s.hist.remove(n.ID())
 }
 
-func (s *dialstate) addDirect(n *enode.Node) {
-	s.direct[n.ID()] = &dialTask{flags: directDialedConn, dest: n}
+func (s *dialstate) addDirect(n *enode.Node, priority int) {
+	s.direct[n.ID()] = &dialTask{flags: directDialedConn, dest: n, priority: priority}
 }
 
 func (s *dialstate) removeDirect(n *enode.Node) {
@@ -218,6 +221,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 		}
 	}
 
+	var directTasks []*dialTask
 	for id, t := range s.direct {
 		err := s.checkDial(t.dest, peers)
 		switch err {
@@ -228,9 +232,20 @@ func (s *dialstate) newTasks(nRunning int, peers map[enode.ID]*Peer, now time.Ti
 			s.dialing[id] = t.flags
 			// New a task instance with no lastResolved, resolveDelay here,
 			// so that we can pass the resolve delay check.
-			newtasks = append(newtasks, &dialTask{flags: t.flags, dest: t.dest})
+			directTasks = append(directTasks, &dialTask{flags: t.flags, dest: t.dest, priority: t.priority})
 		}
 	}
+	// Launch the direct dials of higher priority first, as the number of
+	// running dials is limited.
+	sort.Slice(directTasks, func(i, j int) bool {
+		if directTasks[i].priority != directTasks[j].priority {
+			return directTasks[i].priority > directTasks[j].priority
+		}
+		return bytes.Compare(directTasks[i].dest.ID().Bytes(), directTasks[j].dest.ID().Bytes()) < 0
+	})
+	for _, t := range directTasks {
+		newtasks = append(newtasks, t)
+	}
 
 	// If we don't have any peers whatsoever, try to dial a random bootnode. This
 	// scenario is useful for the testnet (and private networks) where the discovery
@@ -535,7 +535,7 @@ func TestDialStateDirectDial(t *testing.T) {
 	}
 	init := newDialState(enode.ID{}, nil, nil, fakeTable{}, 0, nil)
 	for _, node := range wantDirect {
-		init.addDirect(node)
+		init.addDirect(node, 0)
 	}
 
 	runDialTest(t, dialtest{
@@ -611,6 +611,26 @@ func TestDialStateDirectDial(t *testing.T) {
 	})
 }
 
+// This test checks that direct dials of higher priority are launched first.
+func TestDialStateDirectDialPriority(t *testing.T) {
+	s := newDialState(enode.ID{}, nil, nil, fakeTable{}, 0, nil)
+	s.addDirect(newNode(uintID(1), nil), 0)
+	s.addDirect(newNode(uintID(2), nil), 2)
+	s.addDirect(newNode(uintID(3), nil), 1)
+	s.addDirect(newNode(uintID(4), nil), 2)
+
+	want := []enode.ID{uintID(2), uintID(4), uintID(3), uintID(1)}
+	tasks := s.newTasks(0, nil, time.Time{})
+	if len(tasks) != len(want) {
+		t.Fatalf("task count mismatch: have %d, want %d", len(tasks), len(want))
+	}
+	for i, task := range tasks {
+		if id := task.(*dialTask).dest.ID(); id != want[i] {
+			t.Errorf("task %d: have node %v, want %v", i, id, want[i])
+		}
+	}
+}
+
 // This test checks that static peers will be redialed immediately if they were re-added to a static list.
 func TestDialStaticAfterReset(t *testing.T) {
 	wantStatic := []*enode.Node{
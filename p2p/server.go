@@ -178,7 +178,7 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *enode.Node
 	removestatic  chan *enode.Node
-	adddirect     chan *enode.Node
+	adddirect     chan *directPeer
 	removedirect  chan *enode.Node
 	addtrusted    chan *enode.Node
 	removetrusted chan *enode.Node
@@ -337,8 +337,14 @@ func (srv *Server) RemovePeer(node *enode.Node) {
 // server is shut down. If the connection fails for any reason, the server will
 // attempt to reconnect the peer.
 func (srv *Server) AddDirectPeer(node *enode.Node) {
+	srv.AddPriorityDirectPeer(node, 0)
+}
+
+// AddPriorityDirectPeer is like AddDirectPeer, but direct peers of higher
+// priority are dialed before the others.
+func (srv *Server) AddPriorityDirectPeer(node *enode.Node, priority int) {
 	select {
-	case srv.adddirect <- node:
+	case srv.adddirect <- &directPeer{node, priority}:
 	case <-srv.quit:
 	}
 }
@@ -496,7 +502,7 @@ func (srv *Server) Start() (err error) {
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *enode.Node)
 	srv.removestatic = make(chan *enode.Node)
-	srv.adddirect = make(chan *enode.Node)
+	srv.adddirect = make(chan *directPeer)
 	srv.removedirect = make(chan *enode.Node)
 	srv.addtrusted = make(chan *enode.Node)
 	srv.removetrusted = make(chan *enode.Node)
@@ -659,10 +665,16 @@ type dialer interface {
 	taskDone(task, time.Time)
 	addStatic(*enode.Node)
 	removeStatic(*enode.Node)
-	addDirect(*enode.Node)
+	addDirect(*enode.Node, int)
 	removeDirect(*enode.Node)
 }
 
+// directPeer is a node to keep connected to, with its dial priority.
+type directPeer struct {
+	node     *enode.Node
+	priority int
+}
+
 func (srv *Server) run(dialstate dialer) {
 	srv.log.Info("Started P2P networking", "self", srv.localnode.Node())
 	defer srv.loopWG.Done()
@@ -735,12 +747,12 @@ running:
 			if p, ok := peers[n.ID()]; ok {
 				p.Disconnect(DiscRequested)
 			}
-		case n := <-srv.adddirect:
+		case d := <-srv.adddirect:
 			// This channel is used by AddDirectPeer to add to the
 			// ephemeral direct peer list. Add it to the dialer,
 			// it will keep the node connected.
-			srv.log.Trace("Adding direct node", "node", n)
-			dialstate.addDirect(n)
+			srv.log.Trace("Adding direct node", "node", d.node, "priority", d.priority)
+			dialstate.addDirect(d.node, d.priority)
 		case n := <-srv.removedirect:
 			// This channel is used by RemoveDirectPeer to send a
 			// disconnect request to a peer and begin the
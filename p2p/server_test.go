@@ -339,7 +339,7 @@ func (tg taskgen) addStatic(*enode.Node) {
 }
 func (tg taskgen) removeStatic(*enode.Node) {
 }
-func (tg taskgen) addDirect(*enode.Node) {
+func (tg taskgen) addDirect(*enode.Node, int) {
 }
 func (tg taskgen) removeDirect(*enode.Node) {
 }